@@ -26,7 +26,7 @@ func main() {
 		}
 		fmt.Printf("%-10s", form)
 	}
-	fmt.Println("\n")
+	fmt.Print("\n\n")
 
 	// Example 1: Download the latest 10-K filing for Apple
 	fmt.Println("Example 1: Download the latest 10-K filing for Apple")
@@ -72,15 +72,25 @@ func main() {
 
 	// Example 5: Using the new options pattern
 	fmt.Println("\nExample 5: Using the new options pattern to download 10-K filings for Nvidia")
-	count, err = downloader.GetWithOptions(
+	report, err := downloader.GetWithOptions(
 		"10-K",
 		"NVDA",
 		sec.WithLimit(2),
 		sec.WithDateRange("2021-01-01", nil),
 		sec.WithIncludeAmends(true),
 	)
-	if err != nil {
+	if report == nil {
 		log.Fatalf("Failed to download filings: %v", err)
 	}
-	fmt.Printf("Downloaded %d filings\n", count)
+	fmt.Printf("Downloaded %d filings\n", report.DownloadedCount())
+	for _, filing := range report.Filings {
+		fmt.Printf("  %s %s filed %s in %v\n", filing.AccessionNumber, filing.Form, filing.FilingDate, filing.Duration)
+		for _, doc := range filing.Documents {
+			if doc.Err != nil {
+				fmt.Printf("    %s: %v\n", doc.Kind, doc.Err)
+			} else {
+				fmt.Printf("    %s: %s (%d bytes)\n", doc.Kind, doc.Path, doc.Bytes)
+			}
+		}
+	}
 }
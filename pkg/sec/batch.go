@@ -0,0 +1,294 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// BatchJob describes the filings to download for a single company as part of a batch.
+type BatchJob struct {
+	// TickerOrCIK is the ticker symbol or CIK of the company
+	TickerOrCIK string
+	// Forms lists the form types to download (e.g., "10-K", "10-Q", "8-K")
+	Forms []string
+	// Options configures the downloads of this job; they are applied after the batch options
+	Options []DownloadOption
+}
+
+// BatchResult describes the outcome of downloading one form for one company in a batch.
+type BatchResult struct {
+	// TickerOrCIK is the ticker symbol or CIK of the company, as given in the job
+	TickerOrCIK string
+	// Form is the form type that was downloaded
+	Form string
+	// Report describes every filing downloaded (nil if the download could not start)
+	Report *DownloadReport
+	// Err is the error returned for this form, if any
+	Err error
+}
+
+// BatchReport describes the outcome of a batch download.
+// Results are listed in job order and, within a job, in form order.
+type BatchReport struct {
+	// Results lists the outcome of every (company, form) pair of the batch
+	Results []BatchResult
+}
+
+// DownloadedCount returns the total number of filings downloaded by the batch.
+func (r *BatchReport) DownloadedCount() int {
+	count := 0
+	for _, result := range r.Results {
+		if result.Report != nil {
+			count += result.Report.DownloadedCount()
+		}
+	}
+	return count
+}
+
+// Err returns an error joining the errors of every result, annotated with the
+// company and form, or nil if everything was downloaded.
+func (r *BatchReport) Err() error {
+	var errs []error
+	for _, result := range r.Results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", result.TickerOrCIK, result.Form, result.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// GetBatch downloads several forms for many companies in one call.
+// See GetBatchWithContext for details.
+//
+// Parameters:
+//   - jobs: The companies and forms to download
+//   - options: Variadic list of options applied to every job before the job's own options
+//
+// Returns:
+//   - A report describing every (company, form) pair and nil error on success
+//   - A report and an error joining all errors if anything failed
+//
+// Example: GetBatch([]BatchJob{{TickerOrCIK: "AAPL", Forms: []string{"10-K", "10-Q"}}}, WithLimit(4))
+func (d *Downloader) GetBatch(jobs []BatchJob, options ...DownloadOption) (*BatchReport, error) {
+	return d.GetBatchWithContext(context.Background(), jobs, options...)
+}
+
+// GetBatchWithContext downloads several forms for many companies in one call.
+// Each company's submission history is fetched only once and shared by all of its forms,
+// even if the company appears in several jobs. The concurrency set with WithConcurrency
+// in the batch options bounds both the number of (company, form) pairs processed at once
+// and the total number of filings downloaded at once across all of them; all requests
+// share the SEC client's rate limiter. A filing selected by several pairs of the same
+// company (e.g., "10-K" and "annual reports") is only downloaded once, and is reported
+// by each of them.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - jobs: The companies and forms to download
+//   - options: Variadic list of options applied to every job before the job's own options
+//
+// Returns:
+//   - A report describing every (company, form) pair and nil error on success
+//   - A report and an error joining all errors if anything failed
+func (d *Downloader) GetBatchWithContext(ctx context.Context, jobs []BatchJob, options ...DownloadOption) (*BatchReport, error) {
+	// Determine the number of (company, form) pairs processed at once
+	batchMetadata := &DownloadMetadata{Concurrency: DefaultConcurrency}
+	for _, option := range options {
+		option(batchMetadata)
+	}
+
+	// Expand the jobs into one unit of work per (company, form) pair
+	type unit struct {
+		metadata *DownloadMetadata
+		source   *submissionSource
+	}
+	var results []BatchResult
+	var units []unit
+	sources := make(map[string]*submissionSource)
+	for _, job := range jobs {
+		jobOptions := append(append([]DownloadOption{}, options...), job.Options...)
+		for _, form := range job.Forms {
+			results = append(results, BatchResult{TickerOrCIK: job.TickerOrCIK, Form: form})
+
+			metadata, err := d.newDownloadMetadata(form, job.TickerOrCIK, jobOptions)
+			if err != nil {
+				results[len(results)-1].Err = err
+				units = append(units, unit{})
+				continue
+			}
+
+			// Share one submission source per company
+			source, ok := sources[metadata.CIK]
+			if !ok {
+				source = newSubmissionSource(d.client, metadata.CIK)
+				sources[metadata.CIK] = source
+			}
+			units = append(units, unit{metadata: metadata, source: source})
+		}
+	}
+
+	// Process the units with a bounded pool of workers, sharing the filing downloads
+	pool := newFilingPool(batchMetadata.Concurrency)
+	started := make([]bool, len(units))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(batchMetadata.Concurrency, 1), len(units)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				started[i] = true
+				results[i].Report, results[i].Err = fetchAndSaveFilings(ctx, units[i].metadata, d.client, units[i].source, pool)
+			}
+		}()
+	}
+
+feed:
+	for i, u := range units {
+		if u.metadata == nil {
+			continue
+		}
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	// Record the units that were never started because of cancellation
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		for i, u := range units {
+			if u.metadata != nil && !started[i] {
+				results[i].Err = fmt.Errorf("download not started: %w", ctxErr)
+			}
+		}
+	}
+
+	report := &BatchReport{Results: results}
+	return report, report.Err()
+}
+
+// filingPool is shared by the units of a batch. It bounds the number of filings
+// downloaded at once across all units, and downloads a filing selected by several
+// units only once.
+type filingPool struct {
+	slots chan struct{}
+
+	mu     sync.Mutex
+	claims map[filingClaimKey]*filingClaim
+}
+
+// filingClaimKey identifies the download of a filing: where it is saved, and the options
+// deciding what is downloaded and where it is recorded. Two units downloading a filing
+// with the same key would do the same work; units whose options differ download it
+// separately, so that each one's options apply.
+type filingClaimKey struct {
+	storage  any
+	folder   string
+	key      string
+	details  bool
+	manifest *Manifest
+	// skipExisting, repairMissing and force decide what is downloaded again
+	skipExisting  bool
+	repairMissing bool
+	force         bool
+}
+
+// filingClaim is the download of a filing by the first unit that selected it.
+type filingClaim struct {
+	done   chan struct{}
+	result FilingResult
+}
+
+// newFilingPool creates a pool downloading up to concurrency filings at once.
+func newFilingPool(concurrency int) *filingPool {
+	return &filingPool{
+		slots:  make(chan struct{}, max(concurrency, 1)),
+		claims: make(map[filingClaimKey]*filingClaim),
+	}
+}
+
+// fetch downloads and saves a filing once a slot is free. If another unit already
+// downloads the same filing, it waits for that download and returns its result instead.
+// A nil pool downloads the filing directly.
+func (p *filingPool) fetch(ctx context.Context, metadata *DownloadMetadata, client *SECClient, td ToDownload) FilingResult {
+	if p == nil {
+		return fetchAndSaveFiling(ctx, metadata, client, td)
+	}
+
+	// Claim the filing, unless another unit did
+	key, ok := claimKey(metadata, td)
+	if !ok {
+		return p.fetchInSlot(ctx, metadata, client, td)
+	}
+	p.mu.Lock()
+	claim, claimed := p.claims[key]
+	if !claimed {
+		claim = &filingClaim{done: make(chan struct{})}
+		p.claims[key] = claim
+	}
+	p.mu.Unlock()
+
+	if claimed {
+		select {
+		case <-claim.done:
+			return cloneFilingResult(claim.result)
+		case <-ctx.Done():
+			result := newFilingResult(td)
+			result.Err = ctx.Err()
+			return result
+		}
+	}
+
+	claim.result = p.fetchInSlot(ctx, metadata, client, td)
+	close(claim.done)
+	return claim.result
+}
+
+// fetchInSlot downloads and saves a filing once a slot is free.
+func (p *filingPool) fetchInSlot(ctx context.Context, metadata *DownloadMetadata, client *SECClient, td ToDownload) FilingResult {
+	select {
+	case p.slots <- struct{}{}:
+		defer func() { <-p.slots }()
+		return fetchAndSaveFiling(ctx, metadata, client, td)
+	case <-ctx.Done():
+		result := newFilingResult(td)
+		result.Err = ctx.Err()
+		return result
+	}
+}
+
+// claimKey returns the key identifying where a filing is saved. It reports false if the
+// filing cannot be identified, in which case it is never shared.
+func claimKey(metadata *DownloadMetadata, td ToDownload) (filingClaimKey, bool) {
+	key, err := GetSaveKey(metadata, td, FilingFullSubmissionFilename)
+	if err != nil {
+		return filingClaimKey{}, false
+	}
+	if metadata.Storage != nil && !reflect.TypeOf(metadata.Storage).Comparable() {
+		return filingClaimKey{}, false
+	}
+	return filingClaimKey{
+		storage:       metadata.Storage,
+		folder:        metadata.DownloadFolder,
+		key:           key,
+		details:       metadata.DownloadDetails,
+		manifest:      metadata.Manifest,
+		skipExisting:  metadata.SkipExisting,
+		repairMissing: metadata.RepairMissing,
+		force:         metadata.Force,
+	}, true
+}
+
+// cloneFilingResult returns a copy of a result that shares no slices with it, so that
+// every unit reporting a shared filing gets its own result.
+func cloneFilingResult(result FilingResult) FilingResult {
+	result.Items = append([]string(nil), result.Items...)
+	result.Documents = append([]DocumentResult(nil), result.Documents...)
+	return result
+}
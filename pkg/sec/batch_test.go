@@ -0,0 +1,277 @@
+package sec
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDownloaderGetBatch(t *testing.T) {
+	submissions := map[string]SubmissionData{
+		"/submissions/CIK0000320193.json": {
+			CIK: "320193",
+			Filings: SubmissionFilings{Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000001", "0000320193-22-000002", "0000320193-22-000003"},
+				FilingDate:      []string{"2022-10-28", "2022-07-29", "2022-05-01"},
+				Form:            []string{"10-K", "10-Q", "8-K"},
+				PrimaryDocument: []string{"a.htm", "b.htm", "c.htm"},
+			}},
+		},
+		"/submissions/CIK0000789019.json": {
+			CIK: "789019",
+			Filings: SubmissionFilings{Recent: FilingColumns{
+				AccessionNumber: []string{"0000789019-22-000001", "0000789019-22-000002"},
+				FilingDate:      []string{"2022-07-28", "2022-04-26"},
+				Form:            []string{"10-K", "10-Q"},
+				PrimaryDocument: []string{"d.htm", "e.htm"},
+			}},
+		},
+	}
+
+	var mu sync.Mutex
+	submissionRequests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, DefaultSubmissionsPath) {
+			mu.Lock()
+			submissionRequests[r.URL.Path]++
+			mu.Unlock()
+
+			data, ok := submissions[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(data)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com",
+		WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL, SubmissionsPath: DefaultSubmissionsPath}),
+		WithRateLimit(1000, 10),
+	)
+	downloader, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+		WithSECClient(client),
+		WithTickerToCIKMap(map[string]string{"AAPL": "0000320193", "MSFT": "0000789019"}),
+	)
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+
+	jobs := []BatchJob{
+		{TickerOrCIK: "AAPL", Forms: []string{"10-K", "10-Q", "8-K"}},
+		{TickerOrCIK: "789019", Forms: []string{"10-K", "NOT-A-FORM", "10-Q"}},
+		{TickerOrCIK: "MSFT", Forms: []string{"10-Q"}, Options: []DownloadOption{WithLimit(1)}},
+	}
+
+	report, err := downloader.GetBatch(jobs, WithConcurrency(4))
+	if err == nil {
+		t.Fatalf("GetBatch() error = nil, want error for unsupported form")
+	}
+
+	// Every (company, form) pair is reported in order
+	wantPairs := []string{"AAPL 10-K", "AAPL 10-Q", "AAPL 8-K", "789019 10-K", "789019 NOT-A-FORM", "789019 10-Q", "MSFT 10-Q"}
+	if len(report.Results) != len(wantPairs) {
+		t.Fatalf("GetBatch() returned %d results, want %d", len(report.Results), len(wantPairs))
+	}
+	for i, result := range report.Results {
+		if got := result.TickerOrCIK + " " + result.Form; got != wantPairs[i] {
+			t.Errorf("GetBatch() result %d = %s, want %s", i, got, wantPairs[i])
+		}
+	}
+
+	unsupported := report.Results[4]
+	if unsupported.Err == nil || unsupported.Report != nil {
+		t.Errorf("GetBatch() unsupported form result = %+v, want error without report", unsupported)
+	}
+	if !errors.Is(err, unsupported.Err) {
+		t.Errorf("GetBatch() error = %v, want it to join %v", err, unsupported.Err)
+	}
+
+	for i, result := range report.Results {
+		if i == 4 {
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("GetBatch() %s %s error = %v", result.TickerOrCIK, result.Form, result.Err)
+		}
+		if result.Report == nil || result.Report.DownloadedCount() != 1 {
+			t.Errorf("GetBatch() %s %s report = %+v, want 1 filing", result.TickerOrCIK, result.Form, result.Report)
+		}
+	}
+	if count := report.DownloadedCount(); count != 6 {
+		t.Errorf("DownloadedCount() = %d, want 6", count)
+	}
+
+	// Each company's submissions were fetched exactly once
+	mu.Lock()
+	defer mu.Unlock()
+	for path := range submissions {
+		if submissionRequests[path] != 1 {
+			t.Errorf("GetBatch() fetched %s %d times, want 1", path, submissionRequests[path])
+		}
+	}
+}
+
+func TestDownloaderGetBatchSharesFilingDownloads(t *testing.T) {
+	recent := func(cik string) FilingColumns {
+		return FilingColumns{
+			AccessionNumber: []string{cik + "-22-000001", cik + "-22-000002", cik + "-22-000003", cik + "-22-000004"},
+			FilingDate:      []string{"2022-10-28", "2022-07-29", "2022-04-29", "2022-01-28"},
+			Form:            []string{"10-K", "10-Q", "10-Q", "10-Q"},
+			PrimaryDocument: []string{"a.htm", "b.htm", "c.htm", "d.htm"},
+		}
+	}
+	submissions := map[string]SubmissionData{
+		"/submissions/CIK0000320193.json": {CIK: "320193", Filings: SubmissionFilings{Recent: recent("0000320193")}},
+		"/submissions/CIK0000789019.json": {CIK: "789019", Filings: SubmissionFilings{Recent: recent("0000789019")}},
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	indexRequests := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if data, ok := submissions[r.URL.Path]; ok {
+			json.NewEncoder(w).Encode(data)
+			return
+		}
+
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		if strings.HasSuffix(r.URL.Path, "-index.html") {
+			indexRequests[r.URL.Path]++
+		}
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		w.Write([]byte("content"))
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com",
+		WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL, SubmissionsPath: DefaultSubmissionsPath}),
+		WithRateLimit(1000, 10),
+	)
+	downloader, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+		WithSECClient(client),
+		WithTickerToCIKMap(map[string]string{"AAPL": "0000320193", "MSFT": "0000789019"}),
+	)
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+
+	jobs := []BatchJob{
+		{TickerOrCIK: "AAPL", Forms: []string{"10-K", FormFamilyAnnualReports, "10-Q"}},
+		{TickerOrCIK: "MSFT", Forms: []string{"10-K", "10-Q"}},
+	}
+	report, err := downloader.GetBatch(jobs, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// The concurrency bounds the filings downloaded at once across all pairs
+	if maxInFlight > 2 {
+		t.Errorf("GetBatch() made %d requests at once, want at most 2", maxInFlight)
+	}
+
+	// The annual report selected by two pairs is downloaded once and reported by both
+	for path, count := range indexRequests {
+		if count != 1 {
+			t.Errorf("GetBatch() requested %s %d times, want 1", path, count)
+		}
+	}
+	if len(indexRequests) != 8 {
+		t.Errorf("GetBatch() requested %d index pages, want 8", len(indexRequests))
+	}
+	for _, i := range []int{0, 1} {
+		filings := report.Results[i].Report.Filings
+		if len(filings) != 1 || filings[0].AccessionNumber != "0000320193-22-000001" || !filings[0].Succeeded() || len(filings[0].Documents) != 2 {
+			t.Errorf("GetBatch() AAPL %s filings = %+v, want the shared 10-K", report.Results[i].Form, filings)
+		}
+	}
+}
+
+func TestDownloaderGetBatchKeepsJobOptions(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"a.htm"},
+		}},
+	}
+
+	var mu sync.Mutex
+	indexRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, "-index.html") {
+			mu.Lock()
+			indexRequests++
+			mu.Unlock()
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	client := NewSECClient("TestCompany", "test@example.com",
+		WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL, SubmissionsPath: DefaultSubmissionsPath}),
+		WithRateLimit(1000, 10),
+	)
+	downloader, err := NewDownloader("TestCompany", "test@example.com", folder,
+		WithSECClient(client),
+		WithTickerToCIKMap(map[string]string{"AAPL": "0000320193"}),
+	)
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+	manifest, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+
+	// The same filing is selected by jobs whose options differ
+	jobs := []BatchJob{
+		{TickerOrCIK: "AAPL", Forms: []string{"10-K"}},
+		{TickerOrCIK: "AAPL", Forms: []string{FormFamilyAnnualReports}, Options: []DownloadOption{WithManifest(manifest)}},
+	}
+	report, err := downloader.GetBatch(jobs, WithConcurrency(2))
+	if err != nil {
+		t.Fatalf("GetBatch() error = %v", err)
+	}
+
+	// Each job's options apply, so the filing is downloaded for each and recorded once
+	mu.Lock()
+	defer mu.Unlock()
+	if indexRequests != 2 {
+		t.Errorf("GetBatch() requested the index page %d times, want 2", indexRequests)
+	}
+	if got := len(manifest.FilingEntries("0000320193-22-000001")); got != 2 {
+		t.Errorf("manifest records %d documents of the filing, want 2", got)
+	}
+	for _, result := range report.Results {
+		if filings := result.Report.Filings; len(filings) != 1 || !filings[0].Succeeded() {
+			t.Errorf("GetBatch() %s filings = %+v, want the 10-K", result.Form, filings)
+		}
+	}
+}
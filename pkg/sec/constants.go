@@ -14,22 +14,62 @@ const (
 	// SECRequestsPerSecMax is the maximum number of requests per second allowed by SEC
 	SECRequestsPerSecMax = 10
 
+	// DefaultRateLimitBurst is the default number of requests that may be sent at once
+	DefaultRateLimitBurst = 1
+
+	// DefaultConcurrency is the default number of filings downloaded in parallel
+	DefaultConcurrency = 1
+
+	// DefaultRequestTimeout is the default timeout for a single HTTP request
+	DefaultRequestTimeout = 30 * time.Second
+
+	// DefaultSpoolMaxAge is the age after which the partial downloads kept to be resumed
+	// are removed when a download starts
+	DefaultSpoolMaxAge = 7 * 24 * time.Hour
+
 	// HostWWWSEC is the main SEC website host
+	//
+	// Deprecated: use Endpoints; requests are sent to Endpoints.WWWBaseURL, not to this host.
 	HostWWWSEC = "www.sec.gov"
 
 	// HostDataSEC is the SEC data API host
+	//
+	// Deprecated: use Endpoints; requests are sent to Endpoints.DataBaseURL, not to this host.
 	HostDataSEC = "data.sec.gov"
 
+	// DefaultWWWBaseURL is the base URL of the main SEC website
+	DefaultWWWBaseURL = "https://www.sec.gov"
+
+	// DefaultDataBaseURL is the base URL of the SEC data API
+	DefaultDataBaseURL = "https://data.sec.gov"
+
+	// DefaultArchivesPath is the path of the EDGAR filing archive on the main SEC website
+	DefaultArchivesPath = "/Archives/edgar/data"
+
+	// DefaultSubmissionsPath is the path of the submissions API on the SEC data API
+	DefaultSubmissionsPath = "/submissions"
+
+	// DefaultCIKMappingPath is the path of the CIK mapping file on the main SEC website
+	DefaultCIKMappingPath = "/files/company_tickers_exchange.json"
+
 	// URLCIKMapping is the URL for the CIK mapping file
+	//
+	// Deprecated: use Endpoints.CIKMappingURL; this constant does not affect requests.
 	URLCIKMapping = "https://www.sec.gov/files/company_tickers_exchange.json"
 
 	// URLFiling is the URL template for filing documents
+	//
+	// Deprecated: use Endpoints.FilingURL; this constant does not affect requests.
 	URLFiling = "https://www.sec.gov/Archives/edgar/data/%s/%s/%s"
 
 	// URLFilingArchive is the URL template for the complete filing submission
+	//
+	// Deprecated: use Endpoints.FilingIndexURL; this constant does not affect requests.
 	URLFilingArchive = "https://www.sec.gov/Archives/edgar/data/%s/%s/%s-index.html"
 
 	// URLSubmissions is the URL template for submissions
+	//
+	// Deprecated: use Endpoints.SubmissionsURL; this constant does not affect requests.
 	URLSubmissions = "https://data.sec.gov/submissions/%s"
 
 	// SubmissionFileFormat is the format for submission files
@@ -38,6 +78,12 @@ const (
 	// RootSaveFolderName is the name of the root folder for saved filings
 	RootSaveFolderName = "sec-edgar-filings"
 
+	// DefaultLayout is the layout template documents are saved with unless WithLayout is used
+	DefaultLayout = RootSaveFolderName + "/{ticker}/{form}/{accession}/{filename}"
+
+	// ManifestFilename is the name of the manifest file kept in the root save folder
+	ManifestFilename = "manifest.jsonl"
+
 	// FilingFullSubmissionFilename is the filename for full submissions
 	FilingFullSubmissionFilename = "index.html"
 
@@ -71,8 +117,11 @@ var SupportedForms = map[string]bool{
 	"10-12G":           true,
 	"10-D":             true,
 	"10-K":             true,
+	"10-K405":          true,
+	"10-KSB":           true,
 	"10-KT":            true,
 	"10-Q":             true,
+	"10-QSB":           true,
 	"10-QT":            true,
 	"11-K":             true,
 	"13F":              true,
@@ -1,11 +1,15 @@
 package sec
 
 import (
+	"context"
 	"fmt"
+	"iter"
 	"math"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 )
 
 // DownloadOption represents an option for the Get method.
@@ -71,12 +75,162 @@ func WithAccessionNumbersToSkip(accessionNumbersToSkip map[string]bool) Download
 	}
 }
 
+// WithForms adds form types or form family names to download along with the form
+// passed to GetWithOptions. Unsupported forms make the download request fail.
+// Example: WithForms("10-KT", "20-F") or WithForms(sec.FormFamilyInsiderOwnership)
+func WithForms(forms ...string) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.Forms = append(metadata.Forms, forms...)
+	}
+}
+
+// WithItems restricts the download to filings reporting at least one of the given
+// 8-K item codes (see EightKItems). Filings that report no items, such as most forms
+// other than 8-K, are skipped when this option is used.
+// Example: WithItems("2.02", "5.02") to download earnings releases and officer changes.
+func WithItems(items ...string) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.Items = append(metadata.Items, items...)
+	}
+}
+
+// WithFilter adds a custom predicate that filings must satisfy to be downloaded.
+// Filters receive the metadata of filings that passed the built-in form, date, item and
+// accession number filters; several filters can be combined and must all return true.
+// Example: WithFilter(func(f sec.FilingInfo) bool { return strings.HasSuffix(f.PrimaryDocument, ".htm") })
+func WithFilter(filter FilingFilter) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		if filter != nil {
+			metadata.Filters = append(metadata.Filters, filter)
+		}
+	}
+}
+
+// WithSkipExisting sets whether to skip filings already downloaded.
+// A filing is skipped if every document it would save exists in the storage under its GetSaveKey key;
+// filings with missing documents are downloaded again. Skipped filings still count
+// towards the limit and are reported with FilingResult.Skipped set.
+// Example: WithSkipExisting(true) to make re-running a job download only new filings.
+func WithSkipExisting(skipExisting bool) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.SkipExisting = skipExisting
+	}
+}
+
+// WithRepairMissing sets whether to download only the missing documents of filings
+// already saved, e.g. the details document of filings first downloaded without it.
+// Complete filings are skipped as with WithSkipExisting.
+// Example: WithRepairMissing(true)
+func WithRepairMissing(repairMissing bool) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.RepairMissing = repairMissing
+	}
+}
+
+// WithForce sets whether to download every filing even if it already exists in the storage.
+// It overrides WithSkipExisting and WithRepairMissing.
+// Example: WithForce(true)
+func WithForce(force bool) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.Force = force
+	}
+}
+
+// WithManifest sets the manifest in which every saved document is recorded, with its
+// source URL, HTTP validators, retrieval time, size and SHA-256 checksum.
+// Example: WithManifest(manifest) with a manifest from Downloader.Manifest or OpenManifest.
+func WithManifest(manifest *Manifest) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		metadata.Manifest = manifest
+	}
+}
+
+// WithStorage sets the backend that saved documents are written to.
+// By default, documents are saved as files below the downloader's download folder.
+// If storage is nil, the default is kept.
+// Example: WithStorage(NewMemoryStorage()) or WithStorage(s3Storage) with a storage from NewS3Storage.
+func WithStorage(storage Storage) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		if storage != nil {
+			metadata.Storage = storage
+		}
+	}
+}
+
+// WithConcurrency sets the maximum number of filings downloaded in parallel.
+// All workers share the SEC client's rate limiter, so the overall request rate is unchanged;
+// concurrency only hides the latency of individual requests.
+// If concurrency is less than or equal to 0, filings are downloaded one at a time.
+// Example: WithConcurrency(4) to download up to 4 filings at once.
+func WithConcurrency(concurrency int) DownloadOption {
+	return func(metadata *DownloadMetadata) {
+		if concurrency > 0 {
+			metadata.Concurrency = concurrency
+		} else {
+			metadata.Concurrency = DefaultConcurrency
+		}
+	}
+}
+
+// DownloaderOption represents an option for NewDownloader.
+// It's a function that modifies the configuration used to build a Downloader.
+type DownloaderOption func(*downloaderConfig)
+
+// downloaderConfig holds the configuration collected from DownloaderOptions.
+type downloaderConfig struct {
+	client         *SECClient
+	clientOptions  []ClientOption
+	tickerToCIKMap map[string]string
+	layout         string
+}
+
+// WithSECClient sets a pre-built SEC client to use instead of creating a new one.
+// Client options passed with WithClientOptions are ignored when this option is used.
+// Example: WithSECClient(sec.NewSECClient("YourCompany", "your@email.com"))
+func WithSECClient(client *SECClient) DownloaderOption {
+	return func(config *downloaderConfig) {
+		config.client = client
+	}
+}
+
+// WithClientOptions sets options applied to the SEC client created by NewDownloader.
+// Example: WithClientOptions(sec.WithTimeout(time.Minute), sec.WithRateLimit(5, 1))
+func WithClientOptions(options ...ClientOption) DownloaderOption {
+	return func(config *downloaderConfig) {
+		config.clientOptions = append(config.clientOptions, options...)
+	}
+}
+
+// WithTickerToCIKMap sets a pre-built ticker to CIK mapping, so that NewDownloader does
+// not fetch it from the SEC. Tickers are expected in upper case and CIKs zero-padded to 10 digits.
+// Example: WithTickerToCIKMap(map[string]string{"AAPL": "0000320193"})
+func WithTickerToCIKMap(tickerToCIKMap map[string]string) DownloaderOption {
+	return func(config *downloaderConfig) {
+		config.tickerToCIKMap = tickerToCIKMap
+	}
+}
+
+// WithLayout sets the layout template that determines where documents are saved,
+// relative to the download folder. The template is validated by NewDownloader.
+// Placeholders: {cik}, {ticker}, {form}, {year}, {month}, {day}, {date}, {accession}
+// and {filename}; see ParseLayout for the rules and DefaultLayout for the default.
+// Example: WithLayout("{cik}/{year}/{form}/{accession}/{filename}")
+func WithLayout(template string) DownloaderOption {
+	return func(config *downloaderConfig) {
+		config.layout = template
+	}
+}
+
 // Downloader is the main struct for downloading SEC filings.
 // It provides methods to fetch and save SEC filings for companies and individuals.
 type Downloader struct {
 	client         *SECClient
 	downloadFolder string
 	tickerToCIKMap map[string]string
+	layout         *Layout
+
+	manifestMu sync.Mutex
+	manifest   *Manifest
 }
 
 // NewDownloader creates a new Downloader instance.
@@ -85,15 +239,52 @@ type Downloader struct {
 //   - companyName: Your company name (required by SEC fair access policy)
 //   - emailAddress: Your email address (required by SEC fair access policy)
 //   - downloadFolder: Path to download location (defaults to current working directory)
+//   - options: Variadic list of options to configure the downloader
 //
 // Returns:
 //   - A new Downloader instance and nil error on success
 //   - nil and error on failure
 //
 // Example: NewDownloader("YourCompany", "your@email.com", "downloads")
-func NewDownloader(companyName, emailAddress string, downloadFolder string) (*Downloader, error) {
-	// Create the SEC client
-	client := NewSECClient(companyName, emailAddress)
+func NewDownloader(companyName, emailAddress string, downloadFolder string, options ...DownloaderOption) (*Downloader, error) {
+	return NewDownloaderWithContext(context.Background(), companyName, emailAddress, downloadFolder, options...)
+}
+
+// NewDownloaderWithContext creates a new Downloader instance.
+// The context bounds the request that fetches the ticker to CIK mapping.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - companyName: Your company name (required by SEC fair access policy)
+//   - emailAddress: Your email address (required by SEC fair access policy)
+//   - downloadFolder: Path to download location (defaults to current working directory)
+//   - options: Variadic list of options to configure the downloader
+//
+// Returns:
+//   - A new Downloader instance and nil error on success
+//   - nil and error on failure
+func NewDownloaderWithContext(ctx context.Context, companyName, emailAddress string, downloadFolder string, options ...DownloaderOption) (*Downloader, error) {
+	// Apply options
+	config := &downloaderConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	// Validate the layout
+	layout := defaultLayout
+	if config.layout != "" {
+		var err error
+		layout, err = ParseLayout(config.layout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Create the SEC client unless one was provided
+	client := config.client
+	if client == nil {
+		client = NewSECClient(companyName, emailAddress, config.clientOptions...)
+	}
 
 	// Set the download folder
 	var folder string
@@ -113,46 +304,153 @@ func NewDownloader(companyName, emailAddress string, downloadFolder string) (*Do
 		folder = absPath
 	}
 
-	// Get the ticker to CIK mapping
-	tickerToCIKMap, err := client.GetTickerMetadata()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get ticker to CIK mapping: %w", err)
+	// Get the ticker to CIK mapping unless one was provided
+	tickerToCIKMap := config.tickerToCIKMap
+	if tickerToCIKMap == nil {
+		var err error
+		tickerToCIKMap, err = client.GetTickerMetadataWithContext(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get ticker to CIK mapping: %w", err)
+		}
 	}
 
 	return &Downloader{
 		client:         client,
 		downloadFolder: folder,
 		tickerToCIKMap: tickerToCIKMap,
+		layout:         layout,
 	}, nil
 }
 
+// SetEndpoints sets the endpoints used for all subsequent downloads.
+// This allows targeting an internal EDGAR mirror or a local stand-in server.
+//
+// Parameters:
+//   - endpoints: The endpoints to download filings from
+//
+// Returns:
+//   - nil on success, error if the endpoints are invalid
+func (d *Downloader) SetEndpoints(endpoints Endpoints) error {
+	return d.client.SetEndpoints(endpoints)
+}
+
+// Manifest returns the manifest of the downloader's download folder, opening it on first use.
+// Pass it to WithManifest to record the documents saved by a download.
+//
+// Returns:
+//   - The manifest and nil error on success
+//   - nil and error if the manifest file cannot be read
+//
+// Example:
+//
+//	manifest, err := downloader.Manifest()
+//	report, err := downloader.GetWithOptions("10-K", "AAPL", sec.WithManifest(manifest))
+func (d *Downloader) Manifest() (*Manifest, error) {
+	d.manifestMu.Lock()
+	defer d.manifestMu.Unlock()
+
+	if d.manifest == nil {
+		manifest, err := OpenManifest(d.downloadFolder)
+		if err != nil {
+			return nil, err
+		}
+		d.manifest = manifest
+	}
+	return d.manifest, nil
+}
+
+// RemoveStaleSpools removes the partial downloads that have not been resumed for maxAge.
+// The documents of a filing are spooled in a hidden directory of the download folder (or,
+// with WithStorage, of the system's temporary directory) and kept after an interrupted download, to be
+// resumed by the next one. Downloads already remove spools older than DefaultSpoolMaxAge;
+// this method removes them sooner, e.g. with a maxAge of 0 to remove every spool not in use.
+// The staging directories left behind by downloads that crashed while storing a filing
+// are removed too; nothing else is.
+//
+// Parameters:
+//   - maxAge: The time since a spool was last used after which it is removed
+//   - options: The download options selecting the storage, as passed to GetWithOptions
+//
+// Returns:
+//   - The number of spools removed and nil error on success
+//   - The number of spools removed and error if some could not be removed
+//
+// Example: RemoveStaleSpools(24 * time.Hour)
+func (d *Downloader) RemoveStaleSpools(maxAge time.Duration, options ...DownloadOption) (int, error) {
+	metadata := &DownloadMetadata{DownloadFolder: d.downloadFolder}
+	for _, option := range options {
+		option(metadata)
+	}
+	return removeStaleSpools(spoolRoot(storageOf(metadata)), maxAge)
+}
+
 // GetWithOptions downloads filings for a given form and ticker or CIK with options.
 // It uses the functional options pattern to configure the download.
 //
 // Parameters:
-//   - form: Form type to download (e.g., "8-K", "10-K")
+//   - form: Form type or form family to download (e.g., "8-K", "10-K", "annual reports")
 //   - tickerOrCIK: Ticker symbol or CIK for which to download filings
 //   - options: Variadic list of options to configure the download
 //
 // Returns:
-//   - Number of filings downloaded and nil error on success
-//   - 0 and error on failure
+//   - A report describing every filing and nil error on success
+//   - A report and an error joining the errors of the filings that could not be downloaded
+//   - nil and error if the request is invalid or the filing list could not be fetched
 //
 // Example: GetWithOptions("10-K", "AAPL", WithLimit(5), WithDateRange("2022-01-01", "2023-12-31"))
 func (d *Downloader) GetWithOptions(
 	form string,
 	tickerOrCIK string,
 	options ...DownloadOption,
-) (int, error) {
-	// Check if the form is supported
-	if !SupportedForms[form] {
-		return 0, fmt.Errorf("form %s is not supported", form)
+) (*DownloadReport, error) {
+	return d.GetWithOptionsWithContext(context.Background(), form, tickerOrCIK, options...)
+}
+
+// GetWithOptionsWithContext downloads filings for a given form and ticker or CIK with options.
+// The context is propagated to every request made to the SEC; if it is cancelled, the
+// filings being downloaded are not stored, the parts already downloaded are kept to be
+// resumed by the next download, and the context's error is returned.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - form: Form type or form family to download (e.g., "8-K", "10-K", "annual reports")
+//   - tickerOrCIK: Ticker symbol or CIK for which to download filings
+//   - options: Variadic list of options to configure the download
+//
+// Returns:
+//   - A report describing every filing and nil error on success
+//   - A report and an error joining the errors of the filings that could not be downloaded
+//   - nil and error if the request is invalid or the filing list could not be fetched
+//
+// Example: GetWithOptionsWithContext(ctx, "10-K", "AAPL", WithLimit(5))
+func (d *Downloader) GetWithOptionsWithContext(
+	ctx context.Context,
+	form string,
+	tickerOrCIK string,
+	options ...DownloadOption,
+) (*DownloadReport, error) {
+	// Build the download metadata
+	metadata, err := d.newDownloadMetadata(form, tickerOrCIK, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fetch and save the filings
+	return FetchAndSaveFilingsWithContext(ctx, metadata, d.client)
+}
+
+// newDownloadMetadata validates a download request and builds its metadata,
+// starting from default values and applying the given options.
+func (d *Downloader) newDownloadMetadata(form, tickerOrCIK string, options []DownloadOption) (*DownloadMetadata, error) {
+	// Check if the form is supported or a form family
+	if _, err := ExpandForms(form); err != nil {
+		return nil, err
 	}
 
 	// Validate and convert the ticker or CIK
 	cik, err := ValidateAndConvertTickerOrCIK(tickerOrCIK, d.tickerToCIKMap)
 	if err != nil {
-		return 0, fmt.Errorf("invalid ticker or CIK: %w", err)
+		return nil, fmt.Errorf("invalid ticker or CIK: %w", err)
 	}
 
 	// Create the download metadata with default values
@@ -165,6 +463,8 @@ func (d *Downloader) GetWithOptions(
 		Before:          DefaultBeforeDate,
 		IncludeAmends:   false,
 		DownloadDetails: false,
+		Concurrency:     DefaultConcurrency,
+		Layout:          d.layout,
 	}
 
 	// Apply options
@@ -172,13 +472,20 @@ func (d *Downloader) GetWithOptions(
 		option(metadata)
 	}
 
+	// Expand form families and validate the additional forms
+	forms, err := ExpandForms(append([]string{form}, metadata.Forms...)...)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Form = forms[0]
+	metadata.Forms = forms[1:]
+
 	// If the ticker or CIK is a ticker, set it in the metadata
 	if !IsCIK(tickerOrCIK) {
 		metadata.Ticker = strings.ToUpper(tickerOrCIK)
 	}
 
-	// Fetch and save the filings
-	return FetchAndSaveFilings(metadata, d.client)
+	return metadata, nil
 }
 
 // Get downloads filings for a given form and ticker or CIK.
@@ -196,7 +503,7 @@ func (d *Downloader) GetWithOptions(
 //
 // Returns:
 //   - Number of filings downloaded and nil error on success
-//   - 0 and error on failure
+//   - Number of filings downloaded and error if anything failed (see GetWithOptions for details)
 //
 // Example: Get("10-K", "AAPL", 5, "2022-01-01", "2023-12-31", false, true, nil)
 func (d *Downloader) Get(
@@ -220,7 +527,11 @@ func (d *Downloader) Get(
 		options = append(options, WithAccessionNumbersToSkip(accessionNumbersToSkip))
 	}
 
-	return d.GetWithOptions(form, tickerOrCIK, options...)
+	report, err := d.GetWithOptions(form, tickerOrCIK, options...)
+	if report == nil {
+		return 0, err
+	}
+	return report.DownloadedCount(), err
 }
 
 // GetSupportedForms returns a list of supported form types.
@@ -239,3 +550,47 @@ func (d *Downloader) GetSupportedForms() []string {
 	}
 	return forms
 }
+
+// Filings returns an iterator over the filings of a company, newest first.
+// See FilingsWithContext for details.
+//
+// Parameters:
+//   - tickerOrCIK: Ticker symbol or CIK of the company
+//
+// Returns:
+//   - An iterator yielding the metadata of every filing, or an error that ends the iteration
+//
+// Example:
+//
+//	for filing, err := range downloader.Filings("AAPL") {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(filing.AccessionNumber, filing.Form, filing.FilingDate)
+//	}
+func (d *Downloader) Filings(tickerOrCIK string) iter.Seq2[FilingInfo, error] {
+	return d.FilingsWithContext(context.Background(), tickerOrCIK)
+}
+
+// FilingsWithContext returns an iterator over the filings of a company, newest first.
+// Nothing is fetched until iteration starts; pages of older filings are only fetched when
+// the iteration reaches them, so breaking out of the loop stops all further requests.
+// If a request fails, the error is yielded with an empty FilingInfo and iteration ends.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - tickerOrCIK: Ticker symbol or CIK of the company
+//
+// Returns:
+//   - An iterator yielding the metadata of every filing, or an error that ends the iteration
+func (d *Downloader) FilingsWithContext(ctx context.Context, tickerOrCIK string) iter.Seq2[FilingInfo, error] {
+	// Validate and convert the ticker or CIK
+	cik, err := ValidateAndConvertTickerOrCIK(tickerOrCIK, d.tickerToCIKMap)
+	if err != nil {
+		return func(yield func(FilingInfo, error) bool) {
+			yield(FilingInfo{}, fmt.Errorf("invalid ticker or CIK: %w", err))
+		}
+	}
+
+	return newSubmissionSource(d.client, cik).filings(ctx)
+}
@@ -2,7 +2,10 @@ package sec
 
 import (
 	"math"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"slices"
 	"testing"
 	"time"
 )
@@ -155,6 +158,38 @@ func TestWithIncludeAmends(t *testing.T) {
 	}
 }
 
+func TestWithForms(t *testing.T) {
+	metadata := &DownloadMetadata{}
+	WithForms("10-KT", "20-F")(metadata)
+	WithForms(FormFamilyInsiderOwnership)(metadata)
+
+	want := []string{"10-KT", "20-F", FormFamilyInsiderOwnership}
+	if !slices.Equal(metadata.Forms, want) {
+		t.Errorf("WithForms() set Forms to %v, want %v", metadata.Forms, want)
+	}
+}
+
+func TestWithItems(t *testing.T) {
+	metadata := &DownloadMetadata{}
+	WithItems("2.02")(metadata)
+	WithItems("5.02", "9.01")(metadata)
+
+	want := []string{"2.02", "5.02", "9.01"}
+	if !slices.Equal(metadata.Items, want) {
+		t.Errorf("WithItems() set Items to %v, want %v", metadata.Items, want)
+	}
+}
+
+func TestWithFilter(t *testing.T) {
+	metadata := &DownloadMetadata{}
+	WithFilter(func(FilingInfo) bool { return true })(metadata)
+	WithFilter(nil)(metadata)
+
+	if len(metadata.Filters) != 1 {
+		t.Errorf("WithFilter() set %d filters, want 1", len(metadata.Filters))
+	}
+}
+
 func TestWithDownloadDetails(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -228,6 +263,30 @@ func TestWithAccessionNumbersToSkip(t *testing.T) {
 	}
 }
 
+func TestWithConcurrency(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		want        int
+	}{
+		{name: "Positive concurrency", concurrency: 8, want: 8},
+		{name: "Zero concurrency should set default", concurrency: 0, want: DefaultConcurrency},
+		{name: "Negative concurrency should set default", concurrency: -1, want: DefaultConcurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &DownloadMetadata{}
+			option := WithConcurrency(tt.concurrency)
+			option(metadata)
+
+			if metadata.Concurrency != tt.want {
+				t.Errorf("WithConcurrency() set Concurrency to %v, want %v", metadata.Concurrency, tt.want)
+			}
+		})
+	}
+}
+
 // Mock SEC client for testing the Downloader
 type MockSECClient struct {
 	tickerToCIKMap map[string]string
@@ -302,3 +361,75 @@ func TestDownloaderGet(t *testing.T) {
 		})
 	}
 }
+
+func TestNewDownloaderOptions(t *testing.T) {
+	t.Run("Pre-built ticker map avoids the network", func(t *testing.T) {
+		tickerToCIKMap := map[string]string{"AAPL": "0000320193"}
+		client := NewSECClient("TestCompany", "test@example.com", WithEndpoints(Endpoints{
+			WWWBaseURL:  "http://127.0.0.1:1",
+			DataBaseURL: "http://127.0.0.1:1",
+		}))
+
+		downloader, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+			WithSECClient(client),
+			WithTickerToCIKMap(tickerToCIKMap),
+		)
+		if err != nil {
+			t.Fatalf("NewDownloader() error = %v", err)
+		}
+		if downloader.client != client {
+			t.Errorf("NewDownloader() did not use the provided client")
+		}
+		if !reflect.DeepEqual(downloader.tickerToCIKMap, tickerToCIKMap) {
+			t.Errorf("NewDownloader() tickerToCIKMap = %v, want %v", downloader.tickerToCIKMap, tickerToCIKMap)
+		}
+	})
+
+	t.Run("Client options apply to the created client", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"fields":["cik","name","ticker","exchange"],"data":[[789019,"Microsoft Corporation","MSFT","Nasdaq"]]}`))
+		}))
+		defer server.Close()
+
+		downloader, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+			WithClientOptions(
+				WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL}),
+				WithTimeout(5*time.Second),
+			),
+		)
+		if err != nil {
+			t.Fatalf("NewDownloader() error = %v", err)
+		}
+		if downloader.tickerToCIKMap["MSFT"] != "0000789019" {
+			t.Errorf("NewDownloader() tickerToCIKMap[MSFT] = %v, want 0000789019", downloader.tickerToCIKMap["MSFT"])
+		}
+		if downloader.client.timeout != 5*time.Second {
+			t.Errorf("NewDownloader() client timeout = %v, want %v", downloader.client.timeout, 5*time.Second)
+		}
+	})
+	t.Run("Layout is validated at construction", func(t *testing.T) {
+		tickerToCIKMap := map[string]string{"AAPL": "0000320193"}
+
+		if _, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+			WithTickerToCIKMap(tickerToCIKMap),
+			WithLayout("{cik}/{quarter}/{accession}/{filename}"),
+		); err == nil {
+			t.Errorf("NewDownloader() error = nil, want invalid layout")
+		}
+
+		downloader, err := NewDownloader("TestCompany", "test@example.com", t.TempDir(),
+			WithTickerToCIKMap(tickerToCIKMap),
+			WithLayout("{cik}/{year}/{form}/{accession}/{filename}"),
+		)
+		if err != nil {
+			t.Fatalf("NewDownloader() error = %v", err)
+		}
+		metadata, err := downloader.newDownloadMetadata("10-K", "AAPL", nil)
+		if err != nil {
+			t.Fatalf("newDownloadMetadata() error = %v", err)
+		}
+		if metadata.Layout.String() != "{cik}/{year}/{form}/{accession}/{filename}" {
+			t.Errorf("metadata.Layout = %v, want the configured layout", metadata.Layout)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package sec
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Endpoints describes where the SEC EDGAR resources are served from.
+// The default endpoints point at the SEC itself; other values can be used to target
+// an internal EDGAR mirror or a local stand-in server for tests.
+type Endpoints struct {
+	// WWWBaseURL is the base URL serving the filing archive and the CIK mapping file
+	WWWBaseURL string
+	// DataBaseURL is the base URL serving the submissions API
+	DataBaseURL string
+	// ArchivesPath is the path of the filing archive below WWWBaseURL
+	ArchivesPath string
+	// SubmissionsPath is the path of the submissions API below DataBaseURL
+	SubmissionsPath string
+	// CIKMappingPath is the path of the CIK mapping file below WWWBaseURL
+	CIKMappingPath string
+}
+
+// DefaultEndpoints returns the endpoints of the public SEC EDGAR system.
+//
+// Returns:
+//   - An Endpoints value pointing at www.sec.gov and data.sec.gov
+func DefaultEndpoints() Endpoints {
+	return Endpoints{
+		WWWBaseURL:      DefaultWWWBaseURL,
+		DataBaseURL:     DefaultDataBaseURL,
+		ArchivesPath:    DefaultArchivesPath,
+		SubmissionsPath: DefaultSubmissionsPath,
+		CIKMappingPath:  DefaultCIKMappingPath,
+	}
+}
+
+// Validate checks that the base URLs are absolute HTTP(S) URLs.
+// Empty paths are allowed and mean that resources are served from the base URL itself.
+//
+// Returns:
+//   - nil if the endpoints are valid, error otherwise
+func (e Endpoints) Validate() error {
+	baseURLs := []struct{ name, value string }{
+		{"www", e.WWWBaseURL},
+		{"data", e.DataBaseURL},
+	}
+	for _, base := range baseURLs {
+		name, baseURL := base.name, base.value
+		u, err := url.Parse(baseURL)
+		if err != nil {
+			return fmt.Errorf("invalid %s base URL %q: %w", name, baseURL, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid %s base URL %q: must be an absolute http or https URL", name, baseURL)
+		}
+	}
+	return nil
+}
+
+// SubmissionsURL returns the URL of a submissions file or submissions page.
+//
+// Parameters:
+//   - fileName: The submissions file name (e.g., "CIK0000320193.json")
+//
+// Returns:
+//   - The URL of the submissions file
+func (e Endpoints) SubmissionsURL(fileName string) string {
+	return joinURL(e.DataBaseURL, e.SubmissionsPath, fileName)
+}
+
+// FilingURL returns the URL of a document within a filing.
+//
+// Parameters:
+//   - cik: The Central Index Key of the company
+//   - rawAccNum: The accession number without dashes
+//   - doc: The document file name
+//
+// Returns:
+//   - The URL of the filing document
+func (e Endpoints) FilingURL(cik, rawAccNum, doc string) string {
+	return joinURL(e.WWWBaseURL, e.ArchivesPath, cik, rawAccNum, doc)
+}
+
+// FilingIndexURL returns the URL of the index page of a filing.
+//
+// Parameters:
+//   - cik: The Central Index Key of the company
+//   - rawAccNum: The accession number without dashes
+//   - accNum: The accession number with dashes
+//
+// Returns:
+//   - The URL of the filing index page
+func (e Endpoints) FilingIndexURL(cik, rawAccNum, accNum string) string {
+	return e.FilingURL(cik, rawAccNum, accNum+"-index.html")
+}
+
+// CIKMappingURL returns the URL of the ticker to CIK mapping file.
+//
+// Returns:
+//   - The URL of the CIK mapping file
+func (e Endpoints) CIKMappingURL() string {
+	return joinURL(e.WWWBaseURL, e.CIKMappingPath)
+}
+
+// joinURL joins a base URL and path segments with single slashes.
+func joinURL(base string, segments ...string) string {
+	parts := []string{strings.TrimRight(base, "/")}
+	for _, segment := range segments {
+		if segment = strings.Trim(segment, "/"); segment != "" {
+			parts = append(parts, segment)
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,114 @@
+package sec
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultEndpointsMatchURLTemplates(t *testing.T) {
+	endpoints := DefaultEndpoints()
+
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{
+			name: "Submissions",
+			got:  endpoints.SubmissionsURL("CIK0000320193.json"),
+			want: fmt.Sprintf(URLSubmissions, "CIK0000320193.json"),
+		},
+		{
+			name: "Filing",
+			got:  endpoints.FilingURL("0000320193", "000032019322000001", "aapl.htm"),
+			want: fmt.Sprintf(URLFiling, "0000320193", "000032019322000001", "aapl.htm"),
+		},
+		{
+			name: "Filing index",
+			got:  endpoints.FilingIndexURL("0000320193", "000032019322000001", "0000320193-22-000001"),
+			want: fmt.Sprintf(URLFilingArchive, "0000320193", "000032019322000001", "0000320193-22-000001"),
+		},
+		{
+			name: "CIK mapping",
+			got:  endpoints.CIKMappingURL(),
+			want: URLCIKMapping,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Errorf("URL = %v, want %v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointsCustomLayout(t *testing.T) {
+	endpoints := Endpoints{
+		WWWBaseURL:      "http://mirror.local:8080/edgar/",
+		DataBaseURL:     "http://mirror.local:8080/data",
+		ArchivesPath:    "archive",
+		SubmissionsPath: "/subs/",
+		CIKMappingPath:  "tickers.json",
+	}
+
+	if got, want := endpoints.FilingURL("1", "2", "doc.htm"), "http://mirror.local:8080/edgar/archive/1/2/doc.htm"; got != want {
+		t.Errorf("FilingURL() = %v, want %v", got, want)
+	}
+	if got, want := endpoints.SubmissionsURL("CIK1.json"), "http://mirror.local:8080/data/subs/CIK1.json"; got != want {
+		t.Errorf("SubmissionsURL() = %v, want %v", got, want)
+	}
+	if got, want := endpoints.CIKMappingURL(), "http://mirror.local:8080/edgar/tickers.json"; got != want {
+		t.Errorf("CIKMappingURL() = %v, want %v", got, want)
+	}
+}
+
+func TestEndpointsValidate(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints Endpoints
+		wantErr   bool
+	}{
+		{name: "Defaults", endpoints: DefaultEndpoints(), wantErr: false},
+		{name: "Missing www base URL", endpoints: Endpoints{DataBaseURL: DefaultDataBaseURL}, wantErr: true},
+		{name: "Relative data base URL", endpoints: Endpoints{WWWBaseURL: DefaultWWWBaseURL, DataBaseURL: "data.sec.gov"}, wantErr: true},
+		{name: "Unsupported scheme", endpoints: Endpoints{WWWBaseURL: "ftp://mirror", DataBaseURL: DefaultDataBaseURL}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.endpoints.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			client := NewSECClient("TestCompany", "test@example.com")
+			if err := client.SetEndpoints(tt.endpoints); (err != nil) != tt.wantErr {
+				t.Errorf("SetEndpoints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSECClientGetTickerMetadataFromEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DefaultCIKMappingPath {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"fields":["cik","name","ticker","exchange"],"data":[[320193,"Apple Inc.","AAPL","Nasdaq"]]}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	cikMap, err := client.GetTickerMetadata()
+	if err != nil {
+		t.Fatalf("GetTickerMetadata() error = %v", err)
+	}
+	if cikMap["AAPL"] != "0000320193" {
+		t.Errorf("GetTickerMetadata() AAPL = %v, want 0000320193", cikMap["AAPL"])
+	}
+}
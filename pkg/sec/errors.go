@@ -0,0 +1,157 @@
+package sec
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Sentinel errors returned by the SEC client. They can be matched with errors.Is
+// against any error returned by the client, the orchestrator or the Downloader.
+var (
+	// ErrNotFound indicates that the requested filing or resource does not exist
+	ErrNotFound = errors.New("sec: resource not found")
+	// ErrRateLimited indicates that the SEC is throttling requests
+	ErrRateLimited = errors.New("sec: rate limited")
+	// ErrForbidden indicates that the SEC rejected the request, usually because the
+	// User-Agent does not comply with the fair access policy
+	ErrForbidden = errors.New("sec: access forbidden")
+	// ErrServerError indicates that the SEC failed to process the request
+	ErrServerError = errors.New("sec: server error")
+	// ErrDecode indicates that a response could not be decoded
+	ErrDecode = errors.New("sec: failed to decode response")
+	// ErrUnsafePath indicates that a value cannot be used in the path of a saved document
+	ErrUnsafePath = errors.New("sec: unsafe path component")
+)
+
+// maxSnippetLength is the maximum number of response bytes kept in errors.
+const maxSnippetLength = 512
+
+// HTTPError is returned when the SEC answers a request with a non-200 status code.
+// It matches ErrNotFound, ErrRateLimited, ErrForbidden or ErrServerError with errors.Is,
+// depending on the status code.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+	// URL is the requested URL
+	URL string
+	// Snippet contains the beginning of the response body
+	Snippet string
+	// RetryAfter is the delay requested by the SEC's Retry-After header (0 if absent)
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	msg := fmt.Sprintf("HTTP error %d for %s", e.StatusCode, e.URL)
+	if e.Snippet != "" {
+		msg += fmt.Sprintf(": %q", e.Snippet)
+	}
+	return msg
+}
+
+// Is reports whether the error matches one of the package's sentinel errors.
+func (e *HTTPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || isRateThresholdResponse(e)
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden && !isRateThresholdResponse(e)
+	case ErrServerError:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// isRateThresholdResponse reports whether a 403 response is the SEC's
+// "Request Rate Threshold Exceeded" page rather than a User-Agent rejection.
+func isRateThresholdResponse(e *HTTPError) bool {
+	return e.StatusCode == http.StatusForbidden &&
+		strings.Contains(strings.ToLower(e.Snippet), "request rate threshold exceeded")
+}
+
+// DecodeError is returned when a response from the SEC cannot be decoded.
+// It matches ErrDecode with errors.Is and unwraps to the underlying decoding error.
+type DecodeError struct {
+	// Resource describes what was being decoded (e.g., "submission data")
+	Resource string
+	// URL is the requested URL
+	URL string
+	// Snippet contains the beginning of the response body
+	Snippet string
+	// Err is the underlying decoding error
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode %s from %s: %v", e.Resource, e.URL, e.Err)
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrDecode.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrDecode
+}
+
+// snippetRecorder is an io.Writer that keeps the first maxSnippetLength bytes written to it.
+// It is used with io.TeeReader to capture the beginning of a response body.
+type snippetRecorder struct {
+	buf []byte
+}
+
+func (r *snippetRecorder) Write(p []byte) (int, error) {
+	if remaining := maxSnippetLength - len(r.buf); remaining > 0 {
+		r.buf = append(r.buf, p[:min(remaining, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// String returns the recorded snippet.
+func (r *snippetRecorder) String() string {
+	return strings.TrimSpace(string(r.buf))
+}
+
+// readSnippet reads the beginning of a response body for inclusion in an error.
+func readSnippet(resp *http.Response) string {
+	body, err := getResponseBody(resp)
+	if err != nil {
+		return ""
+	}
+
+	var recorder snippetRecorder
+	_, _ = io.Copy(&recorder, io.LimitReader(body, maxSnippetLength))
+	return recorder.String()
+}
+
+// UnsafePathError is returned when an externally supplied value, such as a document name
+// from the submissions API, a form type, a ticker or an accession number, cannot be used
+// in the path of a saved document because it could escape the download folder or produce
+// an unusable name. It matches ErrUnsafePath with errors.Is.
+type UnsafePathError struct {
+	// Kind describes the rejected value (e.g., "document name")
+	Kind string
+	// Value is the rejected value
+	Value string
+	// Reason explains why the value was rejected
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *UnsafePathError) Error() string {
+	return fmt.Sprintf("unsafe %s %q: %s", e.Kind, e.Value, e.Reason)
+}
+
+// Is reports whether the target is ErrUnsafePath.
+func (e *UnsafePathError) Is(target error) bool {
+	return target == ErrUnsafePath
+}
@@ -0,0 +1,139 @@
+package sec
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHTTPErrorIs(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		retryAfter string
+		want       error
+		notWant    []error
+	}{
+		{
+			name:       "Not found",
+			statusCode: http.StatusNotFound,
+			body:       "Not Found",
+			want:       ErrNotFound,
+			notWant:    []error{ErrRateLimited, ErrForbidden, ErrServerError},
+		},
+		{
+			name:       "Too many requests",
+			statusCode: http.StatusTooManyRequests,
+			retryAfter: "7",
+			want:       ErrRateLimited,
+			notWant:    []error{ErrNotFound, ErrForbidden},
+		},
+		{
+			name:       "Rate threshold exceeded page",
+			statusCode: http.StatusForbidden,
+			body:       "<h1>Request Rate Threshold Exceeded</h1>",
+			want:       ErrRateLimited,
+			notWant:    []error{ErrForbidden},
+		},
+		{
+			name:       "Undeclared automated tool",
+			statusCode: http.StatusForbidden,
+			body:       "Your Request Originates from an Undeclared Automated Tool",
+			want:       ErrForbidden,
+			notWant:    []error{ErrRateLimited},
+		},
+		{
+			name:       "Server error",
+			statusCode: http.StatusBadGateway,
+			want:       ErrServerError,
+			notWant:    []error{ErrNotFound},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewSECClient("TestCompany", "test@example.com")
+			client.SetRetryPolicy(RetryPolicy{MaxAttempts: 1})
+
+			_, err := client.DownloadFiling(server.URL)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("DownloadFiling() error = %v, want errors.Is(%v)", err, tt.want)
+			}
+			for _, notWant := range tt.notWant {
+				if errors.Is(err, notWant) {
+					t.Errorf("DownloadFiling() error = %v, unexpectedly matches %v", err, notWant)
+				}
+			}
+
+			var httpErr *HTTPError
+			if !errors.As(err, &httpErr) {
+				t.Fatalf("DownloadFiling() error = %v, want *HTTPError", err)
+			}
+			if httpErr.StatusCode != tt.statusCode {
+				t.Errorf("HTTPError.StatusCode = %d, want %d", httpErr.StatusCode, tt.statusCode)
+			}
+			if httpErr.URL != server.URL {
+				t.Errorf("HTTPError.URL = %s, want %s", httpErr.URL, server.URL)
+			}
+			if httpErr.Snippet != tt.body {
+				t.Errorf("HTTPError.Snippet = %q, want %q", httpErr.Snippet, tt.body)
+			}
+			if tt.retryAfter != "" && httpErr.RetryAfter != 7*time.Second {
+				t.Errorf("HTTPError.RetryAfter = %v, want %v", httpErr.RetryAfter, 7*time.Second)
+			}
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>maintenance</html>"))
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com")
+
+	_, err := client.GetListOfAvailableFilings(server.URL)
+	if !errors.Is(err, ErrDecode) {
+		t.Fatalf("GetListOfAvailableFilings() error = %v, want errors.Is(ErrDecode)", err)
+	}
+
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("GetListOfAvailableFilings() error = %v, want *DecodeError", err)
+	}
+	if decodeErr.URL != server.URL {
+		t.Errorf("DecodeError.URL = %s, want %s", decodeErr.URL, server.URL)
+	}
+	if decodeErr.Snippet == "" {
+		t.Errorf("DecodeError.Snippet is empty")
+	}
+}
+
+func TestSnippetRecorder(t *testing.T) {
+	var recorder snippetRecorder
+	long := make([]byte, maxSnippetLength*2)
+	for i := range long {
+		long[i] = 'a'
+	}
+
+	n, err := recorder.Write(long)
+	if err != nil || n != len(long) {
+		t.Fatalf("Write() = %d, %v, want %d, nil", n, err, len(long))
+	}
+	if got := len(recorder.String()); got != maxSnippetLength {
+		t.Errorf("snippetRecorder kept %d bytes, want %d", got, maxSnippetLength)
+	}
+}
@@ -0,0 +1,215 @@
+package sec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Names of the predefined form families.
+const (
+	// FormFamilyAnnualReports covers annual reports of domestic and foreign issuers
+	FormFamilyAnnualReports = "annual reports"
+	// FormFamilyQuarterlyReports covers quarterly reports
+	FormFamilyQuarterlyReports = "quarterly reports"
+	// FormFamilyCurrentReports covers current reports of domestic and foreign issuers
+	FormFamilyCurrentReports = "current reports"
+	// FormFamilyInsiderOwnership covers insider ownership reports and their amendments
+	FormFamilyInsiderOwnership = "insider ownership"
+	// FormFamilyBeneficialOwnership covers beneficial ownership reports and their amendments
+	FormFamilyBeneficialOwnership = "beneficial ownership"
+	// FormFamilyProxyStatements covers proxy statements
+	FormFamilyProxyStatements = "proxy statements"
+	// FormFamilyRegistrationStatements covers securities registration statements
+	FormFamilyRegistrationStatements = "registration statements"
+)
+
+// FormFamily is a named group of related form types that can be requested at once.
+type FormFamily struct {
+	// Name is the name used to request the family (e.g., "annual reports")
+	Name string
+	// Forms lists the form types of the family; all of them are in SupportedForms
+	Forms []string
+	// IncludeAmends determines whether amendments of the forms are part of the family
+	IncludeAmends bool
+}
+
+// FormFamilies is a map of the predefined form families by name.
+// Every form of a family is listed in SupportedForms.
+var FormFamilies = map[string]FormFamily{
+	FormFamilyAnnualReports: {
+		Name:  FormFamilyAnnualReports,
+		Forms: []string{"10-K", "10-K405", "10-KSB", "10-KT", "20-F", "40-F"},
+	},
+	FormFamilyQuarterlyReports: {
+		Name:  FormFamilyQuarterlyReports,
+		Forms: []string{"10-Q", "10-QSB", "10-QT"},
+	},
+	FormFamilyCurrentReports: {
+		Name:  FormFamilyCurrentReports,
+		Forms: []string{"8-K", "6-K"},
+	},
+	FormFamilyInsiderOwnership: {
+		Name:          FormFamilyInsiderOwnership,
+		Forms:         []string{"3", "4", "5"},
+		IncludeAmends: true,
+	},
+	FormFamilyBeneficialOwnership: {
+		Name:          FormFamilyBeneficialOwnership,
+		Forms:         []string{"SC 13D", "SC 13G"},
+		IncludeAmends: true,
+	},
+	FormFamilyProxyStatements: {
+		Name:  FormFamilyProxyStatements,
+		Forms: []string{"DEF 14A", "DEFA14A", "DEFM14A", "DEFR14A", "PRE 14A", "PREM14A"},
+	},
+	FormFamilyRegistrationStatements: {
+		Name: FormFamilyRegistrationStatements,
+		Forms: []string{
+			"S-1", "S-3", "S-3ASR", "S-4", "S-8", "S-11",
+			"F-1", "F-3", "F-3ASR", "F-4", "F-10",
+			"10-12B", "10-12G", "8-A12B", "8-A12G",
+		},
+	},
+}
+
+// ExpandForms expands form family names into their form types and validates that
+// every form is supported. A supported form followed by the amendment suffix
+// (e.g., "4/A") is accepted too. Duplicates are removed while preserving order.
+//
+// Parameters:
+//   - forms: Form types and family names to expand
+//
+// Returns:
+//   - The expanded form types and nil error on success
+//   - nil and error if a form is neither supported nor a family name
+//
+// Example: ExpandForms("annual reports", "8-K")
+func ExpandForms(forms ...string) ([]string, error) {
+	var expanded []string
+	seen := make(map[string]bool)
+	add := func(form string) {
+		if !seen[form] {
+			seen[form] = true
+			expanded = append(expanded, form)
+		}
+	}
+
+	for _, form := range forms {
+		// Expand family names, including the amendments if the family covers them
+		if family, ok := FormFamilies[strings.ToLower(strings.TrimSpace(form))]; ok {
+			for _, familyForm := range family.Forms {
+				add(familyForm)
+				if family.IncludeAmends {
+					add(familyForm + AmendsSuffix)
+				}
+			}
+			continue
+		}
+
+		if !SupportedForms[strings.TrimSuffix(form, AmendsSuffix)] {
+			return nil, fmt.Errorf("form %s is not supported", form)
+		}
+		add(form)
+	}
+
+	return expanded, nil
+}
+
+// matchForm reports whether a filing's form matches one of the forms requested by the
+// metadata (Form and Forms) and returns the base form it matched, without the amendment
+// suffix. Amendments (e.g., "10-K/A") match their base form only if metadata.IncludeAmends
+// is set, unless the amendment itself was requested.
+func matchForm(metadata *DownloadMetadata, form string) (string, bool) {
+	requested := append([]string{metadata.Form}, metadata.Forms...)
+	for _, candidate := range requested {
+		if candidate == "" {
+			continue
+		}
+		if strings.EqualFold(form, candidate) ||
+			(metadata.IncludeAmends && strings.EqualFold(form, candidate+AmendsSuffix)) {
+			return strings.TrimSuffix(candidate, AmendsSuffix), true
+		}
+	}
+	return "", false
+}
+
+// formPathEncoder escapes the characters of form types that cannot appear in a path segment.
+var formPathEncoder = strings.NewReplacer("%", "%25", "/", "%2F", "\\", "%5C")
+
+// EncodeFormPath encodes a form type as a single path segment, so that forms such as
+// "10-K/A" do not create nested directories. "%", "/" and "\" are percent-encoded;
+// every other character is kept. The encoding is reversed by DecodeFormPath.
+//
+// Parameters:
+//   - form: The form type to encode
+//
+// Returns:
+//   - The encoded form type
+//
+// Example: EncodeFormPath("10-K/A") returns "10-K%2FA"
+func EncodeFormPath(form string) string {
+	return formPathEncoder.Replace(form)
+}
+
+// DecodeFormPath decodes a path segment produced by EncodeFormPath.
+//
+// Parameters:
+//   - segment: The encoded form type
+//
+// Returns:
+//   - The form type and nil error on success
+//   - An empty string and error if the segment contains an invalid escape sequence
+//
+// Example: DecodeFormPath("10-K%2FA") returns "10-K/A"
+func DecodeFormPath(segment string) (string, error) {
+	var form strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] != '%' {
+			form.WriteByte(segment[i])
+			continue
+		}
+		if i+3 > len(segment) {
+			return "", fmt.Errorf("invalid form path %q: truncated escape sequence", segment)
+		}
+		switch strings.ToUpper(segment[i+1 : i+3]) {
+		case "25":
+			form.WriteByte('%')
+		case "2F":
+			form.WriteByte('/')
+		case "5C":
+			form.WriteByte('\\')
+		default:
+			return "", fmt.Errorf("invalid form path %q: unknown escape sequence %s", segment, segment[i:i+3])
+		}
+		i += 2
+	}
+	return form.String(), nil
+}
+
+// IsAmendment reports whether a form type is an amendment (e.g., "10-K/A").
+//
+// Parameters:
+//   - form: The form type
+//
+// Returns:
+//   - true if the form ends with the amendment suffix, false otherwise
+func IsAmendment(form string) bool {
+	return strings.HasSuffix(strings.ToUpper(form), AmendsSuffix)
+}
+
+// BaseForm returns the form type amended by an amendment, or the form itself if it
+// is not an amendment.
+//
+// Parameters:
+//   - form: The form type
+//
+// Returns:
+//   - The form type without the amendment suffix
+//
+// Example: BaseForm("10-K/A") returns "10-K"
+func BaseForm(form string) string {
+	if IsAmendment(form) {
+		return form[:len(form)-len(AmendsSuffix)]
+	}
+	return form
+}
@@ -0,0 +1,295 @@
+package sec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFormFamiliesAreSupported(t *testing.T) {
+	for name, family := range FormFamilies {
+		if family.Name != name {
+			t.Errorf("FormFamilies[%q].Name = %q", name, family.Name)
+		}
+		for _, form := range family.Forms {
+			if !SupportedForms[form] {
+				t.Errorf("FormFamilies[%q] contains unsupported form %q", name, form)
+			}
+		}
+	}
+}
+
+func TestExpandForms(t *testing.T) {
+	tests := []struct {
+		name    string
+		forms   []string
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:  "Single form",
+			forms: []string{"10-K"},
+			want:  []string{"10-K"},
+		},
+		{
+			name:  "Several forms without duplicates",
+			forms: []string{"10-K", "20-F", "10-K"},
+			want:  []string{"10-K", "20-F"},
+		},
+		{
+			name:  "Annual reports family",
+			forms: []string{"Annual Reports"},
+			want:  []string{"10-K", "10-K405", "10-KSB", "10-KT", "20-F", "40-F"},
+		},
+		{
+			name:  "Insider ownership family includes amendments",
+			forms: []string{FormFamilyInsiderOwnership},
+			want:  []string{"3", "3/A", "4", "4/A", "5", "5/A"},
+		},
+		{
+			name:  "Explicit amendment",
+			forms: []string{"8-K/A"},
+			want:  []string{"8-K/A"},
+		},
+		{
+			name:    "Unsupported form",
+			forms:   []string{"10-K", "NOT-A-FORM"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandForms(tt.forms...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ExpandForms() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ExpandForms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterFilingsMultipleForms(t *testing.T) {
+	filings := &FilingColumns{
+		AccessionNumber: []string{
+			"0000320193-23-000001", "0000320193-23-000002", "0000320193-23-000003",
+			"0000320193-23-000004", "0000320193-23-000005",
+		},
+		FilingDate:      []string{"2023-05-01", "2023-04-01", "2023-03-01", "2023-02-01", "2023-01-01"},
+		Form:            []string{"10-K", "8-K", "20-F/A", "4/A", "10-KT"},
+		PrimaryDocument: []string{"a.htm", "b.htm", "c.htm", "d.xml", "e.htm"},
+	}
+
+	tests := []struct {
+		name          string
+		form          string
+		forms         []string
+		includeAmends bool
+		wantAccNums   []string
+		wantRequested []string
+	}{
+		{
+			name:          "Several forms",
+			form:          "10-K",
+			forms:         []string{"10-KT", "20-F"},
+			wantAccNums:   []string{"0000320193-23-000001", "0000320193-23-000005"},
+			wantRequested: []string{"10-K", "10-KT"},
+		},
+		{
+			name:          "Several forms with amendments",
+			form:          "10-K",
+			forms:         []string{"10-KT", "20-F"},
+			includeAmends: true,
+			wantAccNums:   []string{"0000320193-23-000001", "0000320193-23-000003", "0000320193-23-000005"},
+			wantRequested: []string{"10-K", "20-F", "10-KT"},
+		},
+		{
+			name:          "Explicitly requested amendment",
+			form:          "8-K",
+			forms:         []string{"4/A"},
+			wantAccNums:   []string{"0000320193-23-000002", "0000320193-23-000004"},
+			wantRequested: []string{"8-K", "4"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &DownloadMetadata{
+				Form:          tt.form,
+				Forms:         tt.forms,
+				CIK:           "0000320193",
+				Limit:         10,
+				After:         DefaultAfterDate,
+				Before:        DefaultBeforeDate,
+				IncludeAmends: tt.includeAmends,
+			}
+
+			toDownload, err := filterFilings(metadata, DefaultEndpoints(), filings, nil)
+			if err != nil {
+				t.Fatalf("filterFilings() error = %v", err)
+			}
+
+			var gotAccNums, gotRequested []string
+			for _, td := range toDownload {
+				gotAccNums = append(gotAccNums, td.AccessionNumber)
+				gotRequested = append(gotRequested, td.RequestedForm)
+			}
+			if !slices.Equal(gotAccNums, tt.wantAccNums) {
+				t.Errorf("filterFilings() accession numbers = %v, want %v", gotAccNums, tt.wantAccNums)
+			}
+			if !slices.Equal(gotRequested, tt.wantRequested) {
+				t.Errorf("filterFilings() requested forms = %v, want %v", gotRequested, tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestDownloaderFormFamilies(t *testing.T) {
+	downloader := &Downloader{
+		downloadFolder: "/test/folder",
+		tickerToCIKMap: map[string]string{"AAPL": "0000320193"},
+	}
+
+	metadata, err := downloader.newDownloadMetadata(FormFamilyAnnualReports, "AAPL", []DownloadOption{WithForms("8-K")})
+	if err != nil {
+		t.Fatalf("newDownloadMetadata() error = %v", err)
+	}
+	if metadata.Form != "10-K" {
+		t.Errorf("newDownloadMetadata() Form = %q, want %q", metadata.Form, "10-K")
+	}
+	wantForms := []string{"10-K405", "10-KSB", "10-KT", "20-F", "40-F", "8-K"}
+	if !slices.Equal(metadata.Forms, wantForms) {
+		t.Errorf("newDownloadMetadata() Forms = %v, want %v", metadata.Forms, wantForms)
+	}
+
+	if _, err := downloader.newDownloadMetadata("10-K", "AAPL", []DownloadOption{WithForms("NOT-A-FORM")}); err == nil {
+		t.Errorf("newDownloadMetadata() with an unsupported additional form succeeded, want error")
+	}
+}
+
+func TestEncodeFormPath(t *testing.T) {
+	tests := []struct {
+		form string
+		want string
+	}{
+		{"10-K", "10-K"},
+		{"10-K/A", "10-K%2FA"},
+		{"DEF 14A", "DEF 14A"},
+		{"SC 13D/A", "SC 13D%2FA"},
+		{"50%/A", "50%25%2FA"},
+		{"a\\b", "a%5Cb"},
+		{"%2F", "%252F"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.form, func(t *testing.T) {
+			got := EncodeFormPath(tt.form)
+			if got != tt.want {
+				t.Errorf("EncodeFormPath(%q) = %q, want %q", tt.form, got, tt.want)
+			}
+			decoded, err := DecodeFormPath(got)
+			if err != nil || decoded != tt.form {
+				t.Errorf("DecodeFormPath(%q) = %q, %v, want %q", got, decoded, err, tt.form)
+			}
+		})
+	}
+
+	for _, segment := range []string{"10-K%", "10-K%2", "10-K%41"} {
+		if _, err := DecodeFormPath(segment); err == nil {
+			t.Errorf("DecodeFormPath(%q) error = nil, want error", segment)
+		}
+	}
+}
+
+func TestBaseForm(t *testing.T) {
+	tests := []struct {
+		form          string
+		wantBase      string
+		wantAmendment bool
+	}{
+		{"10-K", "10-K", false},
+		{"10-K/A", "10-K", true},
+		{"SC 13G/A", "SC 13G", true},
+		{"A", "A", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.form, func(t *testing.T) {
+			if got := BaseForm(tt.form); got != tt.wantBase {
+				t.Errorf("BaseForm(%q) = %q, want %q", tt.form, got, tt.wantBase)
+			}
+			if got := IsAmendment(tt.form); got != tt.wantAmendment {
+				t.Errorf("IsAmendment(%q) = %v, want %v", tt.form, got, tt.wantAmendment)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsAmendments(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-23-000002", "0000320193-23-000001"},
+			FilingDate:      []string{"2023-03-01", "2023-02-01"},
+			Form:            []string{"10-K/A", "10-K"},
+			PrimaryDocument: []string{"", ""},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+		IncludeAmends:  true,
+	}
+
+	report, err := FetchAndSaveFilings(metadata, newTestClient(t, server))
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+
+	companyDir := filepath.Join(folder, RootSaveFolderName, "AAPL")
+	entries, err := os.ReadDir(companyDir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	var dirs []string
+	for _, entry := range entries {
+		dirs = append(dirs, entry.Name())
+	}
+	if !slices.Equal(dirs, []string{"10-K", "10-K%2FA"}) {
+		t.Errorf("form directories = %v, want [10-K 10-K%%2FA]", dirs)
+	}
+
+	amendment := report.Filings[0]
+	if amendment.Form != "10-K/A" || !amendment.IsAmendment || amendment.BaseForm != "10-K" {
+		t.Errorf("amendment result Form, IsAmendment, BaseForm = %q, %v, %q", amendment.Form, amendment.IsAmendment, amendment.BaseForm)
+	}
+	if want := filepath.Join(companyDir, "10-K%2FA", "0000320193-23-000002", "index.html"); amendment.Documents[0].Path != want {
+		t.Errorf("amendment saved at %q, want %q", amendment.Documents[0].Path, want)
+	}
+	original := report.Filings[1]
+	if original.IsAmendment || original.BaseForm != "10-K" {
+		t.Errorf("original result IsAmendment, BaseForm = %v, %q", original.IsAmendment, original.BaseForm)
+	}
+}
@@ -33,11 +33,11 @@ func TestIntegrationDownloader(t *testing.T) {
 	}
 
 	// Test fetching a single 10-K for a well-known company (limit=1)
-	count, err := downloader.GetWithOptions("10-K", "AAPL", WithLimit(1))
+	report, err := downloader.GetWithOptions("10-K", "AAPL", WithLimit(1))
 	if err != nil {
 		t.Errorf("GetWithOptions() for AAPL 10-K error: %v", err)
 	} else {
-		t.Logf("Successfully downloaded %d 10-K filing(s) for AAPL", count)
+		t.Logf("Successfully downloaded %d 10-K filing(s) for AAPL", report.DownloadedCount())
 	}
 
 	// Clean up downloaded files
@@ -0,0 +1,89 @@
+package sec
+
+import (
+	"strings"
+)
+
+// EightKItems is a map of 8-K item codes to their descriptions, as reported in the
+// items column of the submissions API (e.g., "2.02,9.01").
+var EightKItems = map[string]string{
+	"1.01": "Entry into a Material Definitive Agreement",
+	"1.02": "Termination of a Material Definitive Agreement",
+	"1.03": "Bankruptcy or Receivership",
+	"1.04": "Mine Safety - Reporting of Shutdowns and Patterns of Violations",
+	"1.05": "Material Cybersecurity Incidents",
+	"2.01": "Completion of Acquisition or Disposition of Assets",
+	"2.02": "Results of Operations and Financial Condition",
+	"2.03": "Creation of a Direct Financial Obligation or an Obligation under an Off-Balance Sheet Arrangement of a Registrant",
+	"2.04": "Triggering Events That Accelerate or Increase a Direct Financial Obligation or an Obligation under an Off-Balance Sheet Arrangement",
+	"2.05": "Costs Associated with Exit or Disposal Activities",
+	"2.06": "Material Impairments",
+	"3.01": "Notice of Delisting or Failure to Satisfy a Continued Listing Rule or Standard; Transfer of Listing",
+	"3.02": "Unregistered Sales of Equity Securities",
+	"3.03": "Material Modification to Rights of Security Holders",
+	"4.01": "Changes in Registrant's Certifying Accountant",
+	"4.02": "Non-Reliance on Previously Issued Financial Statements or a Related Audit Report or Completed Interim Review",
+	"5.01": "Changes in Control of Registrant",
+	"5.02": "Departure of Directors or Certain Officers; Election of Directors; Appointment of Certain Officers; Compensatory Arrangements of Certain Officers",
+	"5.03": "Amendments to Articles of Incorporation or Bylaws; Change in Fiscal Year",
+	"5.04": "Temporary Suspension of Trading Under Registrant's Employee Benefit Plans",
+	"5.05": "Amendments to the Registrant's Code of Ethics, or Waiver of a Provision of the Code of Ethics",
+	"5.06": "Change in Shell Company Status",
+	"5.07": "Submission of Matters to a Vote of Security Holders",
+	"5.08": "Shareholder Director Nominations",
+	"6.01": "ABS Informational and Computational Material",
+	"6.02": "Change of Servicer or Trustee",
+	"6.03": "Change in Credit Enhancement or Other External Support",
+	"6.04": "Failure to Make a Required Distribution",
+	"6.05": "Securities Act Updating Disclosure",
+	"6.06": "Static Pool",
+	"7.01": "Regulation FD Disclosure",
+	"8.01": "Other Events",
+	"9.01": "Financial Statements and Exhibits",
+}
+
+// ParseItems splits the items column of a filing (e.g., "2.02,9.01") into item codes.
+// Surrounding whitespace and an "Item " prefix are removed, and empty entries are dropped.
+//
+// Parameters:
+//   - items: The comma-separated items of a filing
+//
+// Returns:
+//   - A slice containing the item codes, or nil if the filing reports no items
+//
+// Example: ParseItems("2.02, 9.01") returns []string{"2.02", "9.01"}
+func ParseItems(items string) []string {
+	var codes []string
+	for _, item := range strings.Split(items, ",") {
+		if code := normalizeItem(item); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// normalizeItem trims an item code and removes an "Item " prefix.
+func normalizeItem(item string) string {
+	item = strings.TrimSpace(item)
+	if len(item) > len("item ") && strings.EqualFold(item[:len("item ")], "item ") {
+		item = strings.TrimSpace(item[len("item "):])
+	}
+	return item
+}
+
+// matchItems reports whether a filing reporting the given items should be downloaded.
+// All filings match if no items were requested; otherwise the filing must report at
+// least one of the requested items.
+func matchItems(metadata *DownloadMetadata, items []string) bool {
+	if len(metadata.Items) == 0 {
+		return true
+	}
+	for _, requested := range metadata.Items {
+		for _, item := range items {
+			if item == normalizeItem(requested) {
+				return true
+			}
+		}
+	}
+	return false
+}
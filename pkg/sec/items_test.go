@@ -0,0 +1,98 @@
+package sec
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseItems(t *testing.T) {
+	tests := []struct {
+		name  string
+		items string
+		want  []string
+	}{
+		{name: "Empty", items: "", want: nil},
+		{name: "Single item", items: "2.02", want: []string{"2.02"}},
+		{name: "Several items", items: "2.02,9.01", want: []string{"2.02", "9.01"}},
+		{name: "Whitespace and empty entries", items: " 5.02 , ,9.01,", want: []string{"5.02", "9.01"}},
+		{name: "Item prefix", items: "Item 7.01", want: []string{"7.01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseItems(tt.items); !slices.Equal(got, tt.want) {
+				t.Errorf("ParseItems() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterFilingsItems(t *testing.T) {
+	filings := &FilingColumns{
+		AccessionNumber: []string{"0000320193-23-000001", "0000320193-23-000002", "0000320193-23-000003"},
+		FilingDate:      []string{"2023-03-01", "2023-02-01", "2023-01-01"},
+		Form:            []string{"8-K", "8-K", "8-K"},
+		PrimaryDocument: []string{"a.htm", "b.htm", "c.htm"},
+		Items:           []string{"2.02,9.01", "5.02", ""},
+	}
+
+	tests := []struct {
+		name        string
+		items       []string
+		wantAccNums []string
+		wantItems   [][]string
+	}{
+		{
+			name:        "No items filter",
+			wantAccNums: []string{"0000320193-23-000001", "0000320193-23-000002", "0000320193-23-000003"},
+			wantItems:   [][]string{{"2.02", "9.01"}, {"5.02"}, nil},
+		},
+		{
+			name:        "Single item",
+			items:       []string{"2.02"},
+			wantAccNums: []string{"0000320193-23-000001"},
+			wantItems:   [][]string{{"2.02", "9.01"}},
+		},
+		{
+			name:        "Any of several items",
+			items:       []string{"Item 5.02", "2.02"},
+			wantAccNums: []string{"0000320193-23-000001", "0000320193-23-000002"},
+			wantItems:   [][]string{{"2.02", "9.01"}, {"5.02"}},
+		},
+		{
+			name:  "No matching item",
+			items: []string{"1.03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &DownloadMetadata{
+				Form:   "8-K",
+				CIK:    "0000320193",
+				Limit:  10,
+				After:  DefaultAfterDate,
+				Before: DefaultBeforeDate,
+				Items:  tt.items,
+			}
+
+			toDownload, err := filterFilings(metadata, DefaultEndpoints(), filings, nil)
+			if err != nil {
+				t.Fatalf("filterFilings() error = %v", err)
+			}
+
+			var gotAccNums []string
+			var gotItems [][]string
+			for _, td := range toDownload {
+				gotAccNums = append(gotAccNums, td.AccessionNumber)
+				gotItems = append(gotItems, td.Items)
+			}
+			if !slices.Equal(gotAccNums, tt.wantAccNums) {
+				t.Errorf("filterFilings() accession numbers = %v, want %v", gotAccNums, tt.wantAccNums)
+			}
+			if !slices.EqualFunc(gotItems, tt.wantItems, slices.Equal[[]string]) {
+				t.Errorf("filterFilings() items = %v, want %v", gotItems, tt.wantItems)
+			}
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package sec
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// Placeholders available in layout templates.
+const (
+	// LayoutCIK is replaced by the zero-padded CIK of the company (e.g., "0000320193")
+	LayoutCIK = "{cik}"
+	// LayoutTicker is replaced by the ticker symbol, or by the CIK if the download was requested by CIK
+	LayoutTicker = "{ticker}"
+	// LayoutForm is replaced by the form type of the filing, encoded with EncodeFormPath (e.g., "10-K%2FA")
+	LayoutForm = "{form}"
+	// LayoutYear is replaced by the year of the filing date (e.g., "2022")
+	LayoutYear = "{year}"
+	// LayoutMonth is replaced by the two-digit month of the filing date (e.g., "10")
+	LayoutMonth = "{month}"
+	// LayoutDay is replaced by the two-digit day of the filing date (e.g., "28")
+	LayoutDay = "{day}"
+	// LayoutDate is replaced by the filing date in "YYYY-MM-DD" format
+	LayoutDate = "{date}"
+	// LayoutAccession is replaced by the accession number of the filing
+	LayoutAccession = "{accession}"
+	// LayoutFilename is replaced by the file name of the document
+	LayoutFilename = "{filename}"
+)
+
+// layoutPlaceholders is the set of placeholders accepted in layout templates.
+var layoutPlaceholders = map[string]bool{
+	LayoutCIK:       true,
+	LayoutTicker:    true,
+	LayoutForm:      true,
+	LayoutYear:      true,
+	LayoutMonth:     true,
+	LayoutDay:       true,
+	LayoutDate:      true,
+	LayoutAccession: true,
+	LayoutFilename:  true,
+}
+
+// defaultLayout is the parsed DefaultLayout.
+var defaultLayout, _ = ParseLayout(DefaultLayout)
+
+// Layout determines the storage key of every saved document from a template of
+// slash-separated segments, such as "{cik}/{year}/{form}/{accession}/{filename}".
+// A segment may mix literal text and placeholders (e.g., "CIK{cik}" or "{year}-{month}").
+// A Layout is immutable and safe for concurrent use.
+type Layout struct {
+	template string
+	// segments holds the tokens of every segment: literal text or a placeholder
+	segments [][]string
+}
+
+// LayoutFields holds the values substituted into the placeholders of a Layout.
+type LayoutFields struct {
+	// CIK is the Central Index Key of the company
+	CIK string
+	// Ticker is the stock ticker symbol (optional)
+	Ticker string
+	// Form is the form type of the filing (e.g., "10-K/A")
+	Form string
+	// FilingDate is the date when the filing was submitted in "YYYY-MM-DD" format
+	FilingDate string
+	// AccessionNumber is the unique identifier for the filing
+	AccessionNumber string
+	// Filename is the file name of the document
+	Filename string
+}
+
+// ParseLayout parses and validates a layout template.
+// The template must be a relative path whose last segment is exactly {filename} and
+// whose directories include {accession}, so that every filing gets its own directory.
+//
+// Parameters:
+//   - template: The layout template (e.g., "{cik}/{year}/{form}/{accession}/{filename}")
+//
+// Returns:
+//   - The parsed layout and nil error on success
+//   - nil and error if the template is invalid
+//
+// Example: ParseLayout("filings/{cik}/{year}/{form}/{accession}/{filename}")
+func ParseLayout(template string) (*Layout, error) {
+	switch {
+	case template == "":
+		return nil, fmt.Errorf("invalid layout: empty template")
+	case strings.HasPrefix(template, "/"):
+		return nil, fmt.Errorf("invalid layout %q: must be a relative path", template)
+	case strings.ContainsAny(template, "\\\x00"):
+		return nil, fmt.Errorf("invalid layout %q: contains a backslash or NUL byte", template)
+	}
+
+	parts := strings.Split(template, "/")
+	layout := &Layout{template: template, segments: make([][]string, 0, len(parts))}
+	hasAccession := false
+	for i, part := range parts {
+		if part == "" || part == "." || part == ".." {
+			return nil, fmt.Errorf("invalid layout %q: segment %d must not be empty, \".\" or \"..\"", template, i+1)
+		}
+
+		tokens, err := parseLayoutSegment(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid layout %q: %w", template, err)
+		}
+
+		// The file name must be the whole last segment, and only that
+		last := i == len(parts)-1
+		for _, token := range tokens {
+			if token == LayoutFilename && !last {
+				return nil, fmt.Errorf("invalid layout %q: %s must be the last segment", template, LayoutFilename)
+			}
+			if token == LayoutAccession && !last {
+				hasAccession = true
+			}
+		}
+		if last && (len(tokens) != 1 || tokens[0] != LayoutFilename) {
+			return nil, fmt.Errorf("invalid layout %q: the last segment must be %s", template, LayoutFilename)
+		}
+
+		layout.segments = append(layout.segments, tokens)
+	}
+
+	if !hasAccession {
+		return nil, fmt.Errorf("invalid layout %q: a directory segment must contain %s", template, LayoutAccession)
+	}
+
+	return layout, nil
+}
+
+// parseLayoutSegment splits a template segment into literal text and placeholders.
+func parseLayoutSegment(segment string) ([]string, error) {
+	var tokens []string
+	for segment != "" {
+		open := strings.IndexAny(segment, "{}")
+		if open < 0 {
+			tokens = append(tokens, segment)
+			break
+		}
+		if segment[open] == '}' {
+			return nil, fmt.Errorf("unmatched \"}\" in %q", segment)
+		}
+		if open > 0 {
+			tokens = append(tokens, segment[:open])
+		}
+
+		end := strings.IndexAny(segment[open+1:], "{}")
+		if end < 0 || segment[open+1+end] != '}' {
+			return nil, fmt.Errorf("unmatched \"{\" in %q", segment)
+		}
+		placeholder := segment[open : open+end+2]
+		if !layoutPlaceholders[placeholder] {
+			return nil, fmt.Errorf("unknown placeholder %s", placeholder)
+		}
+		tokens = append(tokens, placeholder)
+		segment = segment[open+end+2:]
+	}
+	return tokens, nil
+}
+
+// String returns the template of the layout.
+func (l *Layout) String() string {
+	return l.template
+}
+
+// Key returns the storage key of a document.
+// Every field is validated and sanitized first (see UnsafePathError): the CIK must
+// consist of digits, the accession number must be well-formed, and the ticker, form
+// type and file name must be usable as single file names. Placeholders whose value
+// is unknown, such as date parts without a valid filing date, are replaced by empty
+// strings, and segments left empty are dropped.
+//
+// Parameters:
+//   - fields: The values to substitute into the placeholders
+//
+// Returns:
+//   - The slash-separated storage key of the document and nil error on success
+//   - An empty string and an error matching ErrUnsafePath if a field is rejected
+//
+// Example: Key(LayoutFields{CIK: "0000320193", Form: "10-K", ...})
+func (l *Layout) Key(fields LayoutFields) (string, error) {
+	values, err := fields.values()
+	if err != nil {
+		return "", err
+	}
+
+	segments := make([]string, 0, len(l.segments))
+	for _, tokens := range l.segments {
+		var segment strings.Builder
+		for _, token := range tokens {
+			if value, ok := values[token]; ok {
+				segment.WriteString(value)
+			} else {
+				segment.WriteString(token)
+			}
+		}
+		segments = append(segments, segment.String())
+	}
+
+	return path.Join(segments...), nil
+}
+
+// values returns the validated and sanitized value of every placeholder.
+func (f LayoutFields) values() (map[string]string, error) {
+	if err := validateCIK(f.CIK); err != nil {
+		return nil, err
+	}
+	if err := validateAccessionNumber(f.AccessionNumber); err != nil {
+		return nil, err
+	}
+	filename, err := sanitizePathComponent("document name", f.Filename)
+	if err != nil {
+		return nil, err
+	}
+
+	ticker := f.CIK
+	if f.Ticker != "" {
+		if ticker, err = sanitizePathComponent("ticker", f.Ticker); err != nil {
+			return nil, err
+		}
+	}
+	var form string
+	if f.Form != "" {
+		if form, err = sanitizePathComponent("form type", EncodeFormPath(f.Form)); err != nil {
+			return nil, err
+		}
+	}
+
+	values := map[string]string{
+		LayoutCIK:       f.CIK,
+		LayoutTicker:    ticker,
+		LayoutForm:      form,
+		LayoutYear:      "",
+		LayoutMonth:     "",
+		LayoutDay:       "",
+		LayoutDate:      "",
+		LayoutAccession: f.AccessionNumber,
+		LayoutFilename:  filename,
+	}
+	if date, err := time.Parse(DateFormat, f.FilingDate); err == nil {
+		values[LayoutYear] = date.Format("2006")
+		values[LayoutMonth] = date.Format("01")
+		values[LayoutDay] = date.Format("02")
+		values[LayoutDate] = date.Format(DateFormat)
+	}
+	return values, nil
+}
+
+// layoutOf returns the layout that documents are saved with: the layout set in the
+// metadata, or DefaultLayout.
+func layoutOf(metadata *DownloadMetadata) *Layout {
+	if metadata.Layout != nil {
+		return metadata.Layout
+	}
+	return defaultLayout
+}
@@ -0,0 +1,164 @@
+package sec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLayout(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		wantErr  bool
+	}{
+		{"Default layout", DefaultLayout, false},
+		{"Partitioned by CIK and year", "{cik}/{year}/{form}/{accession}/{filename}", false},
+		{"Mixed segments", "lake/cik={cik}/{year}-{month}/{accession}/{filename}", false},
+		{"Empty", "", true},
+		{"Absolute", "/{cik}/{accession}/{filename}", true},
+		{"Backslash", "{cik}\\{accession}/{filename}", true},
+		{"Parent segment", "../{cik}/{accession}/{filename}", true},
+		{"Empty segment", "{cik}//{accession}/{filename}", true},
+		{"Unknown placeholder", "{cik}/{quarter}/{accession}/{filename}", true},
+		{"Unmatched brace", "{cik/{accession}/{filename}", true},
+		{"Stray closing brace", "cik}/{accession}/{filename}", true},
+		{"Filename not last", "{cik}/{filename}/{accession}", true},
+		{"Filename mixed with text", "{cik}/{accession}/doc-{filename}", true},
+		{"Missing accession", "{cik}/{form}/{filename}", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := ParseLayout(tt.template)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLayout(%q) error = %v, wantErr %v", tt.template, err, tt.wantErr)
+			}
+			if err == nil && layout.String() != tt.template {
+				t.Errorf("String() = %q, want %q", layout.String(), tt.template)
+			}
+		})
+	}
+}
+
+func TestLayoutKey(t *testing.T) {
+	fields := LayoutFields{
+		CIK:             "0000320193",
+		Ticker:          "AAPL",
+		Form:            "10-K",
+		FilingDate:      "2022-10-28",
+		AccessionNumber: "0000320193-22-000108",
+		Filename:        "index.html",
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		modify   func(*LayoutFields)
+		want     string
+	}{
+		{
+			name:     "Default layout",
+			template: DefaultLayout,
+			want:     "sec-edgar-filings/AAPL/10-K/0000320193-22-000108/index.html",
+		},
+		{
+			name:     "Ticker falls back to CIK",
+			template: DefaultLayout,
+			modify:   func(f *LayoutFields) { f.Ticker = "" },
+			want:     "sec-edgar-filings/0000320193/10-K/0000320193-22-000108/index.html",
+		},
+		{
+			name:     "Date parts",
+			template: "{cik}/{year}/{month}/{day}/{date}/{accession}/{filename}",
+			want:     "0000320193/2022/10/28/2022-10-28/0000320193-22-000108/index.html",
+		},
+		{
+			name:     "Mixed segments",
+			template: "cik={cik}/{year}-{month}/{form}_{accession}/{filename}",
+			want:     "cik=0000320193/2022-10/10-K_0000320193-22-000108/index.html",
+		},
+		{
+			name:     "Unknown filing date drops the segment",
+			template: "{cik}/{year}/{accession}/{filename}",
+			modify:   func(f *LayoutFields) { f.FilingDate = "" },
+			want:     "0000320193/0000320193-22-000108/index.html",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layout, err := ParseLayout(tt.template)
+			if err != nil {
+				t.Fatalf("ParseLayout() error = %v", err)
+			}
+			f := fields
+			if tt.modify != nil {
+				tt.modify(&f)
+			}
+			got, err := layout.Key(f)
+			if err != nil {
+				t.Fatalf("Key() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsWithLayout(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000108"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm"},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	layout, err := ParseLayout("{cik}/{year}/{form}/{accession}/{filename}")
+	if err != nil {
+		t.Fatalf("ParseLayout() error = %v", err)
+	}
+	folder := t.TempDir()
+	metadata := &DownloadMetadata{
+		DownloadFolder:  folder,
+		Form:            "10-K",
+		CIK:             "0000320193",
+		Ticker:          "AAPL",
+		Limit:           10,
+		After:           DefaultAfterDate,
+		Before:          DefaultBeforeDate,
+		DownloadDetails: true,
+		Layout:          layout,
+	}
+
+	report, err := FetchAndSaveFilings(metadata, newTestClient(t, server))
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+
+	filingDir := filepath.Join(folder, "0000320193", "2022", "10-K", "0000320193-22-000108")
+	for _, name := range []string{"index.html", "aapl-20220924.htm", "indexaapl-20220924-index-headers.html"} {
+		if _, err := os.Stat(filepath.Join(filingDir, name)); err != nil {
+			t.Errorf("document %s not saved in %s: %v", name, filingDir, err)
+		}
+	}
+	if docs := report.Filings[0].Documents; len(docs) != 3 || docs[2].Kind != DocumentDetails || filepath.Dir(docs[2].Path) != filingDir {
+		t.Errorf("Documents = %+v, want the details document in %s", docs, filingDir)
+	}
+}
@@ -0,0 +1,380 @@
+package sec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ManifestEntry records the provenance of a saved document.
+type ManifestEntry struct {
+	// AccessionNumber is the unique identifier for the filing
+	AccessionNumber string `json:"accessionNumber"`
+	// CIK is the Central Index Key of the company
+	CIK string `json:"cik"`
+	// Ticker is the stock ticker symbol if the download was requested by ticker
+	Ticker string `json:"ticker,omitempty"`
+	// Form is the SEC form type of the filing
+	Form string `json:"form"`
+	// IsAmendment reports whether the filing amends an earlier filing (e.g., a "10-K/A")
+	IsAmendment bool `json:"isAmendment,omitempty"`
+	// FilingDate is the date when the filing was submitted in "YYYY-MM-DD" format
+	FilingDate string `json:"filingDate"`
+	// Kind is the role of the document within the filing
+	Kind DocumentKind `json:"kind"`
+	// Path is the storage key of the document, which for files is the location of the
+	// document relative to the download folder
+	Path string `json:"path"`
+	// URL is the URL the document was downloaded from
+	URL string `json:"url"`
+	// LastModified is the Last-Modified header returned by the SEC (may be empty)
+	LastModified string `json:"lastModified,omitempty"`
+	// ETag is the ETag header returned by the SEC (may be empty)
+	ETag string `json:"etag,omitempty"`
+	// RetrievedAt is the time when the document was downloaded
+	RetrievedAt time.Time `json:"retrievedAt"`
+	// Size is the size of the document in bytes
+	Size int64 `json:"size"`
+	// SHA256 is the hex-encoded SHA-256 checksum of the document
+	SHA256 string `json:"sha256"`
+}
+
+// manifestKey is the storage key of the manifest file in a file system storage rooted at
+// the download folder
+const manifestKey = RootSaveFolderName + "/" + ManifestFilename
+
+// Manifest is a JSON Lines file recording every document saved in a download folder.
+// There is one entry per saved path; downloading a document again replaces its entry.
+// Each update is appended to the file and synced, so recording costs the size of the
+// new entries only. Replaced entries and a line left incomplete by an interrupted
+// append are dropped when the manifest is opened, which compacts the file.
+// A Manifest is safe for concurrent use.
+type Manifest struct {
+	folder string
+	path   string
+
+	mu      sync.Mutex
+	entries []ManifestEntry
+	index   map[string]int
+}
+
+// OpenManifest opens the manifest of a download folder, loading its entries if the
+// manifest file exists. The file is located at <downloadFolder>/sec-edgar-filings/manifest.jsonl.
+// If the file holds replaced entries or ends with an incomplete line, it is rewritten
+// atomically with the current entries only. Compactions and appends take a lock file, so
+// that several processes may record documents in the same manifest.
+//
+// Parameters:
+//   - downloadFolder: The download folder whose documents the manifest records
+//
+// Returns:
+//   - The manifest and nil error on success
+//   - nil and error if the manifest file cannot be read, decoded or compacted
+//
+// Example: OpenManifest("downloads")
+func OpenManifest(downloadFolder string) (*Manifest, error) {
+	m := &Manifest{
+		folder: downloadFolder,
+		path:   filepath.Join(downloadFolder, RootSaveFolderName, ManifestFilename),
+		index:  make(map[string]int),
+	}
+
+	// Step 1: Load the entries
+	compact, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 2: Compact the file if it holds more than the current entries. Other processes
+	// may append to it meanwhile, so it is loaded again while holding its lock.
+	if compact {
+		lock, err := m.lockFile()
+		if err != nil {
+			return nil, err
+		}
+		defer lock.unlock()
+
+		if compact, err = m.load(); err != nil {
+			return nil, err
+		}
+		if compact {
+			if err := m.compact(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// load reads the entries of the manifest file, the last one recorded for a path winning,
+// and reports whether the file should be compacted because it holds replaced entries or
+// ends with an incomplete line.
+func (m *Manifest) load() (bool, error) {
+	m.entries = nil
+	m.index = make(map[string]int)
+
+	file, err := os.Open(m.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	lines, compact := 0, false
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return false, fmt.Errorf("failed to read manifest: %w", err)
+		}
+		last := err != nil
+
+		if len(bytes.TrimSpace(data)) > 0 {
+			var entry ManifestEntry
+			if err := json.Unmarshal(data, &entry); err != nil {
+				if last {
+					// The final append was interrupted before the line was complete
+					compact = true
+					break
+				}
+				return false, fmt.Errorf("failed to decode manifest line %d: %w", line, err)
+			}
+			m.put(entry)
+			lines++
+			// A line without a newline would be joined with the next append
+			compact = compact || last
+		}
+		if last {
+			break
+		}
+	}
+	return compact || lines != len(m.entries), nil
+}
+
+// lockFile waits until this process holds the lock of the manifest file, which keeps
+// other processes from appending to the file while it is compacted. The lock file is
+// kept in the spool directory of the download folder, outside of the stored objects.
+func (m *Manifest) lockFile() (*fileLock, error) {
+	dir := filepath.Join(m.folder, spoolDirName)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	lock, err := waitLockFile(filepath.Join(dir, ManifestFilename+spoolLockFilename), manifestLockStaleAge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock manifest: %w", err)
+	}
+	return lock, nil
+}
+
+// Path returns the location of the manifest file.
+func (m *Manifest) Path() string {
+	return m.path
+}
+
+// Record adds entries to the manifest and appends them to the manifest file, syncing
+// it to disk. Entries with the path of an existing entry replace it.
+//
+// Parameters:
+//   - entries: The entries to record
+//
+// Returns:
+//   - nil on success, error if the manifest file cannot be written
+func (m *Manifest) Record(entries ...ManifestEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	// Step 1: Encode the new entries
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode manifest entry: %w", err)
+		}
+	}
+
+	// Step 2: Append them in a single write, so lines of concurrent records never interleave,
+	// holding the lock of the file so that another process does not compact it meanwhile
+	m.mu.Lock()
+	lock, err := m.lockFile()
+	if err != nil {
+		m.mu.Unlock()
+		return err
+	}
+	file, err := m.appendLines(buf.Bytes())
+	if err == nil {
+		for _, entry := range entries {
+			m.put(entry)
+		}
+	}
+	lock.unlock()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// Step 3: Sync outside of the lock, so workers do not wait for each other's syncs
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to sync manifest: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close manifest: %w", err)
+	}
+	return nil
+}
+
+// appendLines appends encoded entries to the manifest file and returns the open file.
+func (m *Manifest) appendLines(lines []byte) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(m.path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %w", err)
+	}
+	file, err := os.OpenFile(m.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	if _, err := file.Write(lines); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return file, nil
+}
+
+// compact rewrites the manifest file atomically with the current entries. The caller
+// holds the lock of the file.
+func (m *Manifest) compact() error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range m.entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode manifest entry: %w", err)
+		}
+	}
+
+	if _, err := writeFileAtomic(m.path, &buf); err != nil {
+		return fmt.Errorf("failed to compact manifest: %w", err)
+	}
+	return nil
+}
+
+// put adds or replaces an entry in memory.
+func (m *Manifest) put(entry ManifestEntry) {
+	if i, ok := m.index[entry.Path]; ok {
+		m.entries[i] = entry
+		return
+	}
+	m.index[entry.Path] = len(m.entries)
+	m.entries = append(m.entries, entry)
+}
+
+// Entries returns all entries of the manifest, in the order they were first recorded.
+func (m *Manifest) Entries() []ManifestEntry {
+	return m.Find(func(ManifestEntry) bool { return true })
+}
+
+// Find returns the entries of the manifest that satisfy the predicate.
+//
+// Parameters:
+//   - match: The predicate selecting entries
+//
+// Returns:
+//   - A slice containing the matching entries
+//
+// Example: Find(func(e ManifestEntry) bool { return e.Form == "10-K" })
+func (m *Manifest) Find(match func(ManifestEntry) bool) []ManifestEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var entries []ManifestEntry
+	for _, entry := range m.entries {
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// FilingEntries returns the entries of the documents saved for a filing.
+//
+// Parameters:
+//   - accessionNumber: The accession number of the filing
+//
+// Returns:
+//   - A slice containing the entries of the filing's documents
+func (m *Manifest) FilingEntries(accessionNumber string) []ManifestEntry {
+	return m.Find(func(entry ManifestEntry) bool {
+		return entry.AccessionNumber == accessionNumber
+	})
+}
+
+// Lookup returns the entry of a saved document.
+//
+// Parameters:
+//   - path: The location of the document, absolute or relative to the download folder
+//
+// Returns:
+//   - The entry and true if the document is recorded, false otherwise
+func (m *Manifest) Lookup(path string) (ManifestEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i, ok := m.index[m.relativePath(path)]
+	if !ok {
+		return ManifestEntry{}, false
+	}
+	return m.entries[i], true
+}
+
+// relativePath returns a path relative to the download folder, or the path itself
+// if it is already relative or outside of the folder.
+func (m *Manifest) relativePath(path string) string {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(path)
+	}
+	folder, err := filepath.Abs(m.folder)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(folder, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// newManifestEntries builds the manifest entries of the documents saved for a filing.
+func (m *Manifest) newManifestEntries(metadata *DownloadMetadata, result *FilingResult) []ManifestEntry {
+	var entries []ManifestEntry
+	for _, doc := range result.Documents {
+		if doc.Err != nil || doc.Key == "" || doc.Skipped {
+			continue
+		}
+		entries = append(entries, ManifestEntry{
+			AccessionNumber: result.AccessionNumber,
+			CIK:             metadata.CIK,
+			Ticker:          metadata.Ticker,
+			Form:            result.Form,
+			IsAmendment:     result.IsAmendment,
+			FilingDate:      result.FilingDate,
+			Kind:            doc.Kind,
+			Path:            doc.Key,
+			URL:             doc.URL,
+			LastModified:    doc.LastModified,
+			ETag:            doc.ETag,
+			RetrievedAt:     doc.RetrievedAt,
+			Size:            doc.Bytes,
+			SHA256:          doc.SHA256,
+		})
+	}
+	return entries
+}
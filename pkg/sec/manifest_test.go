@@ -0,0 +1,245 @@
+package sec
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestManifestRecordsSavedDocuments(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm"},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		w.Header().Set("Last-Modified", "Fri, 28 Oct 2022 18:01:14 GMT")
+		w.Header().Set("ETag", `"abc123"`)
+		w.Write([]byte("content of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	manifest, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+		Manifest:       manifest,
+	}
+
+	report, err := FetchAndSaveFilings(metadata, newTestClient(t, server))
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+
+	entries := manifest.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Entries() returned %d entries, want 2", len(entries))
+	}
+	for i, doc := range report.Filings[0].Documents {
+		entry := entries[i]
+		contents, err := os.ReadFile(doc.Path)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		checksum := sha256.Sum256(contents)
+
+		want := ManifestEntry{
+			AccessionNumber: "0000320193-22-000001",
+			CIK:             "0000320193",
+			Ticker:          "AAPL",
+			Form:            "10-K",
+			FilingDate:      "2022-10-28",
+			Kind:            doc.Kind,
+			Path:            filepath.ToSlash(filepath.Join(RootSaveFolderName, "AAPL", "10-K", "0000320193-22-000001", doc.Name)),
+			URL:             doc.URL,
+			LastModified:    "Fri, 28 Oct 2022 18:01:14 GMT",
+			ETag:            `"abc123"`,
+			RetrievedAt:     doc.RetrievedAt,
+			Size:            int64(len(contents)),
+			SHA256:          hex.EncodeToString(checksum[:]),
+		}
+		if !reflect.DeepEqual(entry, want) {
+			t.Errorf("Entries()[%d] = %+v, want %+v", i, entry, want)
+		}
+		if doc.SHA256 != want.SHA256 || entry.RetrievedAt.IsZero() {
+			t.Errorf("DocumentResult %s SHA256 = %q, RetrievedAt = %v", doc.Kind, doc.SHA256, doc.RetrievedAt)
+		}
+
+		if got, ok := manifest.Lookup(doc.Path); !ok || got.Path != want.Path {
+			t.Errorf("Lookup(%q) = %+v, %v", doc.Path, got, ok)
+		}
+	}
+
+	// Downloading again replaces the entries instead of duplicating them
+	if _, err := FetchAndSaveFilings(metadata, newTestClient(t, server)); err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+	if got := len(manifest.FilingEntries("0000320193-22-000001")); got != 2 {
+		t.Errorf("FilingEntries() returned %d entries, want 2", got)
+	}
+
+	// The manifest file can be reopened and no temporary file is left behind
+	reopened, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+	if got, want := reopened.Entries(), manifest.Entries(); len(got) != len(want) || got[0].SHA256 != want[0].SHA256 || !got[0].RetrievedAt.Equal(want[0].RetrievedAt) {
+		t.Errorf("reopened Entries() = %+v, want %+v", got, want)
+	}
+	files, err := os.ReadDir(filepath.Dir(manifest.Path()))
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	for _, file := range files {
+		if file.Name() != ManifestFilename && file.Name() != "AAPL" {
+			t.Errorf("unexpected file %s next to the manifest", file.Name())
+		}
+	}
+}
+
+func TestOpenManifestInvalid(t *testing.T) {
+	folder := t.TempDir()
+	path := filepath.Join(folder, RootSaveFolderName, ManifestFilename)
+	if err := SaveDocument([]byte("{not json}\n"), path); err != nil {
+		t.Fatalf("SaveDocument() error = %v", err)
+	}
+
+	if _, err := OpenManifest(folder); err == nil {
+		t.Errorf("OpenManifest() with an invalid manifest succeeded, want error")
+	}
+}
+
+func TestManifestAppendsAndCompacts(t *testing.T) {
+	folder := t.TempDir()
+	manifest, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+
+	first := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "a.htm", SHA256: "1"}
+	second := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "b.htm", SHA256: "2"}
+	replaced := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "a.htm", SHA256: "3"}
+	for _, entry := range []ManifestEntry{first, second, replaced} {
+		if err := manifest.Record(entry); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	// Every record is appended as a line
+	if got := countLines(t, manifest.Path()); got != 3 {
+		t.Errorf("manifest has %d lines after 3 records, want 3", got)
+	}
+
+	// An interrupted append leaves an incomplete last line
+	file, err := os.OpenFile(manifest.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("os.OpenFile() error = %v", err)
+	}
+	file.WriteString(`{"accessionNumber":"0000320193-22`)
+	file.Close()
+
+	// Reopening drops the replaced entry and the incomplete line and compacts the file
+	reopened, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+	if got, want := reopened.Entries(), []ManifestEntry{replaced, second}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened Entries() = %+v, want %+v", got, want)
+	}
+	if got := countLines(t, manifest.Path()); got != 2 {
+		t.Errorf("manifest has %d lines after reopening, want 2", got)
+	}
+}
+
+func TestManifestCompactionLock(t *testing.T) {
+	folder := t.TempDir()
+	manifest, err := OpenManifest(folder)
+	if err != nil {
+		t.Fatalf("OpenManifest() error = %v", err)
+	}
+	first := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "a.htm", SHA256: "1"}
+	replaced := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "a.htm", SHA256: "2"}
+	appended := ManifestEntry{AccessionNumber: "0000320193-22-000001", Path: "b.htm", SHA256: "3"}
+	if err := manifest.Record(first, replaced); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	// Another process holds the lock while it appends an entry
+	lock, err := manifest.lockFile()
+	if err != nil {
+		t.Fatalf("lockFile() error = %v", err)
+	}
+	opened := make(chan *Manifest)
+	go func() {
+		reopened, err := OpenManifest(folder)
+		if err != nil {
+			t.Errorf("OpenManifest() error = %v", err)
+		}
+		opened <- reopened
+	}()
+	time.Sleep(5 * lockPollInterval)
+	line, _ := json.Marshal(appended)
+	file, err := os.OpenFile(manifest.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("os.OpenFile() error = %v", err)
+	}
+	file.Write(append(line, '\n'))
+	file.Close()
+	lock.unlock()
+
+	// The compaction waited for the lock and kept the appended entry
+	reopened := <-opened
+	if got, want := reopened.Entries(), []ManifestEntry{replaced, appended}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened Entries() = %+v, want %+v", got, want)
+	}
+	if got := countLines(t, manifest.Path()); got != 2 {
+		t.Errorf("manifest has %d lines after reopening, want 2", got)
+	}
+
+	// The manifest is not one of the stored objects
+	objects, err := NewFileSystemStorage(folder).List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(objects) != 0 {
+		t.Errorf("List() = %+v, want no objects", objects)
+	}
+}
+
+// countLines returns the number of lines of a file.
+func countLines(t *testing.T, path string) int {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("os.ReadFile() error = %v", err)
+	}
+	return bytes.Count(data, []byte("\n"))
+}
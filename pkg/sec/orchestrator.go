@@ -1,15 +1,24 @@
 package sec
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 )
 
 // GetSaveLocation returns the path where a filing should be saved.
-// It constructs a directory path based on the company identifier, form type, and accession number.
+// It constructs a directory path from the metadata's layout (DefaultLayout unless
+// WithLayout is used), based on the company identifier, metadata.Form, and accession number.
+// The filing's date and actual form are unknown here, so the {year}, {month}, {day}
+// and {date} placeholders are left empty and their segments dropped: with a date layout,
+// or for an amendment, the path differs from where the document is actually saved.
 //
 // Parameters:
 //   - metadata: The download metadata containing configuration options
@@ -17,24 +26,75 @@ import (
 //   - saveFilename: The filename to save the document as
 //
 // Returns:
-//   - A string containing the full path where the filing should be saved
+//   - A string containing the full path where the filing should be saved, or an empty
+//     string if a component of the path is unsafe, which callers must not write to
+//
+// Deprecated: use GetSaveKey with the filing's ToDownload, which also returns the
+// UnsafePathError rejecting a path, and join the key to the download folder with
+// filepath.FromSlash.
 func GetSaveLocation(metadata *DownloadMetadata, accessionNumber, saveFilename string) string {
-	companyIdentifier := metadata.Ticker
-	if companyIdentifier == "" {
-		companyIdentifier = metadata.CIK
+	key, err := GetSaveKey(metadata, ToDownload{AccessionNumber: accessionNumber}, saveFilename)
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(metadata.DownloadFolder, filepath.FromSlash(key))
+}
+
+// GetSaveKey returns the storage key under which a filing document should be saved,
+// relative to the download folder, by applying the metadata's layout to the filing.
+// Filings are saved under their actual form (td.Form, or metadata.Form if it is empty),
+// encoded with EncodeFormPath, so amendments are kept apart from the original filings
+// (e.g., "10-K%2FA" next to "10-K"). Every component of the key is validated and
+// sanitized, so that the key never escapes the download folder.
+//
+// Parameters:
+//   - metadata: The download metadata containing configuration options
+//   - td: The filing the document belongs to
+//   - saveFilename: The filename to save the document as
+//
+// Returns:
+//   - A string containing the key under which the document should be saved and nil error on success
+//   - An empty string and an error matching ErrUnsafePath if a component is rejected
+//
+// Example: GetSaveKey(metadata, td, "index.html") returns
+// "sec-edgar-filings/AAPL/10-K/0000320193-22-000108/index.html" with the default layout
+func GetSaveKey(metadata *DownloadMetadata, td ToDownload, saveFilename string) (string, error) {
+	form := td.Form
+	if form == "" {
+		form = metadata.Form
 	}
 
-	return filepath.Join(
-		metadata.DownloadFolder,
-		RootSaveFolderName,
-		companyIdentifier,
-		metadata.Form,
-		accessionNumber,
-		saveFilename,
-	)
+	return layoutOf(metadata).Key(LayoutFields{
+		CIK:             metadata.CIK,
+		Ticker:          metadata.Ticker,
+		Form:            form,
+		FilingDate:      td.FilingDate,
+		AccessionNumber: td.AccessionNumber,
+		Filename:        saveFilename,
+	})
+}
+
+// storageOf returns the storage that documents are saved to: the storage set in the
+// metadata, or the file system below the download folder.
+func storageOf(metadata *DownloadMetadata) Storage {
+	if metadata.Storage != nil {
+		return metadata.Storage
+	}
+	return NewFileSystemStorage(metadata.DownloadFolder)
+}
+
+// storagePath returns the local file that stores a key, or an empty string if the
+// storage does not keep objects on the local file system.
+func storagePath(storage Storage, key string) string {
+	if fsStorage, ok := storage.(*FileSystemStorage); ok {
+		return fsStorage.Path(key)
+	}
+	return ""
 }
 
 // SaveDocument saves a document to disk, creating any necessary directories.
+// The document is written to a temporary file in the same directory, synced and renamed
+// into place, so that a crash or cancellation never leaves a truncated file at savePath.
 //
 // Parameters:
 //   - filingContents: The raw content of the filing as a byte slice
@@ -43,21 +103,51 @@ func GetSaveLocation(metadata *DownloadMetadata, accessionNumber, saveFilename s
 // Returns:
 //   - nil on success, error if the save operation fails
 func SaveDocument(filingContents []byte, savePath string) error {
-	// Create all parent directories as needed
-	if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
-		return fmt.Errorf("failed to create directories: %w", err)
+	_, err := writeFileAtomic(savePath, bytes.NewReader(filingContents))
+	return err
+}
+
+// writeFileAtomic writes the contents of r to a temporary file in the destination
+// directory and renames it over path once it is synced, so that readers never see a
+// partial file. It returns the number of bytes written.
+func writeFileAtomic(path string, r io.Reader) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create directories: %w", err)
 	}
 
-	// Write content to file
-	if err := os.WriteFile(savePath, filingContents, 0644); err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create temporary file: %w", err)
 	}
+	defer os.Remove(tmp.Name())
 
-	return nil
+	n, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return n, fmt.Errorf("failed to write temporary file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return n, fmt.Errorf("failed to sync temporary file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return n, fmt.Errorf("failed to close temporary file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return n, fmt.Errorf("failed to set file permissions: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return n, fmt.Errorf("failed to rename temporary file: %w", err)
+	}
+	return n, nil
 }
 
 // AggregateFilingsToDownload aggregates the filings to download based on download metadata.
 // It fetches the filing list from the SEC and filters it according to the specified criteria.
+// Filings that are older than the "filings.recent" window are loaded from the paginated
+// submission files, but only the pages whose date span overlaps the requested date range
+// are fetched.
 //
 // Parameters:
 //   - metadata: The download metadata containing filtering options
@@ -67,33 +157,83 @@ func SaveDocument(filingContents []byte, savePath string) error {
 //   - A slice of ToDownload objects and nil error on success
 //   - nil and error on failure
 func AggregateFilingsToDownload(metadata *DownloadMetadata, client *SECClient) ([]ToDownload, error) {
-	// Format the submission URL
-	submissionFile := fmt.Sprintf(SubmissionFileFormat, metadata.CIK)
-	submissionURL := fmt.Sprintf(URLSubmissions, submissionFile)
+	return AggregateFilingsToDownloadWithContext(context.Background(), metadata, client)
+}
+
+// AggregateFilingsToDownloadWithContext aggregates the filings to download based on download metadata.
+// The context is propagated to every request made to the SEC.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - metadata: The download metadata containing filtering options
+//   - client: The SEC client to use for API requests
+//
+// Returns:
+//   - A slice of ToDownload objects and nil error on success
+//   - nil and error on failure
+func AggregateFilingsToDownloadWithContext(ctx context.Context, metadata *DownloadMetadata, client *SECClient) ([]ToDownload, error) {
+	return aggregateFilings(ctx, metadata, client, newSubmissionSource(client, metadata.CIK))
+}
 
+// aggregateFilings aggregates the filings to download from a submission source.
+func aggregateFilings(ctx context.Context, metadata *DownloadMetadata, client *SECClient, source *submissionSource) ([]ToDownload, error) {
 	// Get the list of available filings
-	submissionData, err := client.GetListOfAvailableFilings(submissionURL)
+	submissionData, err := source.submissions(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get list of available filings: %w", err)
+		return nil, err
+	}
+
+	// Filter the most recent filings first
+	toDownload, err := filterFilings(metadata, client.endpoints, &submissionData.Filings.Recent, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk the older pages, newest first, until the limit is reached
+	for _, file := range newestSubmissionFilesFirst(submissionData.Filings.Files) {
+		if len(toDownload) >= metadata.Limit {
+			break
+		}
+
+		// Skip pages that cannot contain filings in the requested date range
+		if !submissionFileOverlaps(metadata, file) {
+			continue
+		}
+
+		page, err := source.page(ctx, file.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		toDownload, err = filterFilings(metadata, client.endpoints, page, toDownload)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	// Filter the filings based on the metadata
-	filings := submissionData.Filings.Recent
-	var toDownload []ToDownload
+	return toDownload, nil
+}
+
+// filterFilings appends the filings of a columnar filing list that match the download
+// metadata to toDownload, stopping once the limit has been reached.
+func filterFilings(metadata *DownloadMetadata, endpoints Endpoints, filings *FilingColumns, toDownload []ToDownload) ([]ToDownload, error) {
 	for i := 0; i < len(filings.AccessionNumber) && len(toDownload) < metadata.Limit; i++ {
-		// Get the form for this filing
-		form := filings.Form[i]
+		// Skip rows with missing columns
+		if i >= len(filings.Form) || i >= len(filings.FilingDate) {
+			continue
+		}
 
-		// Skip if form doesn't match and we're not checking for amendments
-		if !strings.EqualFold(form, metadata.Form) {
-			if !metadata.IncludeAmends || !strings.EqualFold(form, metadata.Form+AmendsSuffix) {
-				continue
-			}
+		// Get the metadata of this filing
+		info := filings.Filing(i)
+
+		// Skip if the form doesn't match any requested form
+		requestedForm, ok := matchForm(metadata, info.Form)
+		if !ok {
+			continue
 		}
 
 		// Parse the filing date
-		filingDateStr := filings.FilingDate[i]
-		filingDate, err := time.Parse(DateFormat, filingDateStr)
+		filingDate, err := time.Parse(DateFormat, info.FilingDate)
 		if err != nil {
 			// Skip filings with invalid dates
 			continue
@@ -104,18 +244,35 @@ func AggregateFilingsToDownload(metadata *DownloadMetadata, client *SECClient) (
 			continue
 		}
 
+		// Skip filings that report none of the requested items
+		if !matchItems(metadata, info.Items) {
+			continue
+		}
+
 		// Skip filings with specified accession numbers
-		accessionNumber := filings.AccessionNumber[i]
-		if metadata.AccessionNumbersToSkip != nil && metadata.AccessionNumbersToSkip[accessionNumber] {
+		if metadata.AccessionNumbersToSkip != nil && metadata.AccessionNumbersToSkip[info.AccessionNumber] {
+			continue
+		}
+
+		// Skip filings rejected by a custom filter
+		if !matchFilters(metadata, info) {
 			continue
 		}
 
-		// Get the document to download
-		doc := filings.PrimaryDocument[i]
-		td, err := GetToDownload(metadata.CIK, accessionNumber, doc)
+		// Get the document to download. Entries with unsafe names are kept so that
+		// they are reported as failed filings.
+		td, err := GetToDownloadWithEndpoints(endpoints, metadata.CIK, info.AccessionNumber, info.PrimaryDocument)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get download URL for accession number %s: %w", accessionNumber, err)
+			td = &ToDownload{
+				AccessionNumber: info.AccessionNumber,
+				Err:             fmt.Errorf("rejected filing %s: %w", info.AccessionNumber, err),
+			}
 		}
+		td.Form = info.Form
+		td.RequestedForm = requestedForm
+		td.FilingDate = info.FilingDate
+		td.Items = info.Items
+		td.Info = info
 
 		// Add to the list
 		toDownload = append(toDownload, *td)
@@ -124,6 +281,32 @@ func AggregateFilingsToDownload(metadata *DownloadMetadata, client *SECClient) (
 	return toDownload, nil
 }
 
+// matchFilters reports whether a filing is accepted by every custom filter of the metadata.
+func matchFilters(metadata *DownloadMetadata, info FilingInfo) bool {
+	for _, filter := range metadata.Filters {
+		if !filter(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// submissionFileOverlaps reports whether the date span of a submissions page overlaps
+// the date range requested in the download metadata. Pages with missing or malformed
+// dates are assumed to overlap so that no filings are silently dropped.
+func submissionFileOverlaps(metadata *DownloadMetadata, file SubmissionFile) bool {
+	from, err := time.Parse(DateFormat, file.FilingFrom)
+	if err != nil {
+		return true
+	}
+	to, err := time.Parse(DateFormat, file.FilingTo)
+	if err != nil {
+		return true
+	}
+
+	return !to.Before(metadata.After) && !from.After(metadata.Before)
+}
+
 // GetToDownload constructs a ToDownload object with the appropriate URLs for a filing.
 //
 // Parameters:
@@ -135,21 +318,41 @@ func AggregateFilingsToDownload(metadata *DownloadMetadata, client *SECClient) (
 //   - A ToDownload object and nil error on success
 //   - nil and error on failure
 func GetToDownload(cik, accNum, doc string) (*ToDownload, error) {
-	// Remove dashes from accession number
-	rawAccNum := strings.ReplaceAll(accNum, "-", "")
+	return GetToDownloadWithEndpoints(DefaultEndpoints(), cik, accNum, doc)
+}
 
-	// Calculate the URLs for the filing
-	if len(rawAccNum) != 18 {
-		return nil, fmt.Errorf("invalid accession number: %s", accNum)
+// GetToDownloadWithEndpoints constructs a ToDownload object with URLs built from the given endpoints.
+//
+// Parameters:
+//   - endpoints: The endpoints serving the filing archive
+//   - cik: The Central Index Key of the company
+//   - accNum: The accession number of the filing
+//   - doc: The primary document filename
+//
+// Returns:
+//   - A ToDownload object and nil error on success
+//   - nil and error on failure
+func GetToDownloadWithEndpoints(endpoints Endpoints, cik, accNum, doc string) (*ToDownload, error) {
+	// Validate the values that end up in URLs and paths
+	if err := validateAccessionNumber(accNum); err != nil {
+		return nil, fmt.Errorf("invalid accession number: %w", err)
+	}
+	if doc != "" {
+		if err := validateDocumentName(doc); err != nil {
+			return nil, fmt.Errorf("invalid primary document: %w", err)
+		}
 	}
 
+	// Remove dashes from accession number
+	rawAccNum := strings.ReplaceAll(accNum, "-", "")
+
 	// Calculate the base URL and archive URLs
-	rawFilingURL := fmt.Sprintf(URLFilingArchive, cik, rawAccNum, accNum)
+	rawFilingURL := endpoints.FilingIndexURL(cik, rawAccNum, accNum)
 
 	// Determine the primary document URI if available
 	var primaryDocURI string
 	if doc != "" {
-		primaryDocURI = fmt.Sprintf(URLFiling, cik, rawAccNum, doc)
+		primaryDocURI = endpoints.FilingURL(cik, rawAccNum, doc)
 	}
 
 	// Determine the details document suffix (e.g., for form 4 XML)
@@ -176,57 +379,322 @@ func GetToDownload(cik, accNum, doc string) (*ToDownload, error) {
 //   - client: The SEC client to use for API requests
 //
 // Returns:
-//   - The number of filings downloaded and nil error on success
-//   - 0 and error on failure
-func FetchAndSaveFilings(metadata *DownloadMetadata, client *SECClient) (int, error) {
+//   - A report describing every filing and nil error on success
+//   - A report and an error joining the errors of the filings that could not be downloaded
+//   - nil and error if the filings to download could not be determined
+func FetchAndSaveFilings(metadata *DownloadMetadata, client *SECClient) (*DownloadReport, error) {
+	return FetchAndSaveFilingsWithContext(context.Background(), metadata, client)
+}
+
+// FetchAndSaveFilingsWithContext fetches and saves filings based on the download metadata.
+// Up to metadata.Concurrency filings are downloaded in parallel; all workers share the
+// client's rate limiter. If the context is cancelled, nothing is stored for the filings
+// still in progress, and the returned error wraps the context's error. The parts of their
+// documents already downloaded are kept in a spool directory, so that the next download
+// of these filings resumes them instead of starting over.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - metadata: The download metadata containing configuration options
+//   - client: The SEC client to use for API requests
+//
+// Returns:
+//   - A report describing every filing and nil error on success
+//   - A report and an error joining the errors of the filings that could not be downloaded
+//   - nil and error if the filings to download could not be determined
+func FetchAndSaveFilingsWithContext(ctx context.Context, metadata *DownloadMetadata, client *SECClient) (*DownloadReport, error) {
+	return fetchAndSaveFilings(ctx, metadata, client, newSubmissionSource(client, metadata.CIK), nil)
+}
+
+// fetchAndSaveFilings fetches and saves the filings selected from a submission source.
+// If pool is not nil, the filings are downloaded through it (see filingPool).
+func fetchAndSaveFilings(ctx context.Context, metadata *DownloadMetadata, client *SECClient, source *submissionSource, pool *filingPool) (*DownloadReport, error) {
+	// Remove the partial downloads abandoned long ago
+	_, _ = removeStaleSpools(spoolRoot(storageOf(metadata)), DefaultSpoolMaxAge)
+
 	// Get the list of filings to download
-	toDownload, err := AggregateFilingsToDownload(metadata, client)
+	toDownload, err := aggregateFilings(ctx, metadata, client, source)
 	if err != nil {
-		return 0, fmt.Errorf("failed to aggregate filings to download: %w", err)
+		return nil, fmt.Errorf("failed to aggregate filings to download: %w", err)
 	}
 
-	// Download and save each filing
-	downloadCount := 0
-	for _, td := range toDownload {
-		// Download index.html
-		indexContents, err := client.DownloadFiling(td.RawFilingURI)
-		if err != nil {
-			continue
-		}
+	// Download and save the filings with a bounded pool of workers.
+	// Results are stored by index so that the report order does not depend on scheduling.
+	results := make([]FilingResult, len(toDownload))
+	started := make([]bool, len(toDownload))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(max(metadata.Concurrency, 1), len(toDownload)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				started[i] = true
+				results[i] = pool.fetch(ctx, metadata, client, toDownload[i])
+			}
+		}()
+	}
 
-		// Save index.html
-		savePath := GetSaveLocation(metadata, td.AccessionNumber, FilingFullSubmissionFilename)
-		if err := SaveDocument(indexContents, savePath); err != nil {
-			continue
+feed:
+	for i := range toDownload {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
 		}
+	}
+	close(jobs)
+	wg.Wait()
 
-		// Download primary document if available
-		if td.PrimaryDocURI != "" {
-			primaryContents, err := client.DownloadFiling(td.PrimaryDocURI)
-			if err == nil {
-				// Extract filename from primary document URI
-				_, primaryFileName := filepath.Split(td.PrimaryDocURI)
-				primarySavePath := GetSaveLocation(metadata, td.AccessionNumber, primaryFileName)
-				_ = SaveDocument(primaryContents, primarySavePath)
+	// Record the filings never started because of cancellation
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		for i := range results {
+			if !started[i] {
+				results[i] = newFilingResult(toDownload[i])
+				results[i].Err = fmt.Errorf("download not started: %w", ctxErr)
 			}
 		}
+	}
+
+	report := &DownloadReport{
+		CIK:     metadata.CIK,
+		Ticker:  metadata.Ticker,
+		Filings: results,
+	}
+
+	return report, report.Err()
+}
+
+// newFilingResult returns an empty result for the given filing.
+func newFilingResult(td ToDownload) FilingResult {
+	return FilingResult{
+		AccessionNumber: td.AccessionNumber,
+		Form:            td.Form,
+		BaseForm:        BaseForm(td.Form),
+		IsAmendment:     IsAmendment(td.Form),
+		FilingDate:      td.FilingDate,
+		Items:           td.Items,
+	}
+}
 
-		// Download details document if requested
-		if metadata.DownloadDetails && td.DetailsDocSuffix != "" {
-			// Calculate the details URL
-			rawAccNum := strings.ReplaceAll(td.AccessionNumber, "-", "")
-			detailsURL := fmt.Sprintf(URLFiling, metadata.CIK, rawAccNum, rawAccNum+td.DetailsDocSuffix)
+// fetchAndSaveFiling downloads and saves the documents of a single filing.
+// The filing fails if its index page cannot be saved or if the download is cancelled;
+// failures of optional documents are only recorded in the document results.
+// The documents are streamed to spool files until all of them have been downloaded,
+// and are then stored together, so that a failed download leaves nothing behind in the
+// storage and no document is ever held in memory. If the download was interrupted or
+// failed transiently, the spool files that can be resumed are kept, and the next attempt
+// resumes them with HTTP range requests.
+func fetchAndSaveFiling(ctx context.Context, metadata *DownloadMetadata, client *SECClient, td ToDownload) FilingResult {
+	start := time.Now()
+	result := newFilingResult(td)
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
 
-			// Download details document
-			detailsContents, err := client.DownloadFiling(detailsURL)
-			if err == nil {
-				detailsSavePath := GetSaveLocation(metadata, td.AccessionNumber, fmt.Sprintf("index%s", td.DetailsDocSuffix))
-				_ = SaveDocument(detailsContents, detailsSavePath)
+	storage := storageOf(metadata)
+	docs, err := filingDocuments(metadata, client.endpoints, td)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// Skip the filing, or the documents of it, that already exist in the storage
+	existing := make(map[string]bool)
+	if !metadata.Force && (metadata.SkipExisting || metadata.RepairMissing) {
+		complete := true
+		for _, doc := range docs {
+			exists, err := storage.Exists(ctx, doc.key)
+			if err != nil {
+				result.Err = err
+				return result
 			}
+			existing[doc.key] = exists
+			complete = complete && exists
+		}
+		result.Skipped = complete
+
+		// Without repair, incomplete filings are downloaded again entirely
+		if !complete && !metadata.RepairMissing {
+			clear(existing)
+		}
+	}
+	if result.Skipped {
+		for _, doc := range docs {
+			result.Documents = append(result.Documents, skippedDocument(doc))
+		}
+		return result
+	}
+
+	// Download the documents
+	spool, err := newFilingSpool(spoolRoot(storage), td.AccessionNumber)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer func() {
+		spool.release(result.Err)
+	}()
+
+	var objects []StorageObject
+	var stored []int
+	for _, doc := range docs {
+		if existing[doc.key] {
+			result.Documents = append(result.Documents, skippedDocument(doc))
+			continue
+		}
+
+		saved := fetchDocument(ctx, client, spool, doc)
+		result.Documents = append(result.Documents, saved)
+		if saved.Err == nil {
+			objects = append(objects, StorageObject{
+				Key:      doc.key,
+				Metadata: documentMetadata(metadata, td, saved),
+			})
+			stored = append(stored, len(result.Documents)-1)
+		}
+
+		// The index page is required; other documents are optional
+		if doc.kind == DocumentIndex && saved.Err != nil {
+			result.Err = saved.Err
+			return result
+		}
+		if doc.kind != DocumentIndex && ctx.Err() != nil {
+			result.Err = ctx.Err()
+			return result
 		}
+	}
 
-		downloadCount++
+	// Store the downloaded documents once all of them have been fetched
+	if err := spool.store(ctx, storage, objects); err != nil {
+		result.Err = fmt.Errorf("failed to store filing %s: %w", td.AccessionNumber, err)
+		return result
+	}
+	spool.discard()
+	for _, i := range stored {
+		result.Documents[i].Key = docs[i].key
+		result.Documents[i].Path = docs[i].path
 	}
 
-	return downloadCount, nil
+	// Record the saved documents in the manifest
+	if metadata.Manifest != nil {
+		if err := metadata.Manifest.Record(metadata.Manifest.newManifestEntries(metadata, &result)...); err != nil {
+			result.Err = err
+		}
+	}
+
+	return result
+}
+
+// skippedDocument returns the result of a document that already exists in the storage.
+func skippedDocument(doc filingDocument) DocumentResult {
+	return DocumentResult{
+		Kind:    doc.kind,
+		Name:    doc.fileName,
+		URL:     doc.uri,
+		Key:     doc.key,
+		Path:    doc.path,
+		Skipped: true,
+	}
+}
+
+// filingDocument describes a document of a filing to download and where to save it.
+type filingDocument struct {
+	kind     DocumentKind
+	uri      string
+	fileName string
+	// key is the storage key of the document
+	key string
+	// path is the local file of the document, if the storage is the file system
+	path string
+}
+
+// filingDocuments returns the documents to download for a filing: the index page, the
+// primary document if available, and the details document if requested.
+// It fails if the filing was rejected or if the save key of a document is unsafe.
+func filingDocuments(metadata *DownloadMetadata, endpoints Endpoints, td ToDownload) ([]filingDocument, error) {
+	if td.Err != nil {
+		return nil, td.Err
+	}
+
+	storage := storageOf(metadata)
+	var docs []filingDocument
+	addDocument := func(kind DocumentKind, uri, fileName string) error {
+		key, err := GetSaveKey(metadata, td, fileName)
+		if err != nil {
+			return fmt.Errorf("rejected %s document of filing %s: %w", kind, td.AccessionNumber, err)
+		}
+		docs = append(docs, filingDocument{
+			kind:     kind,
+			uri:      uri,
+			fileName: path.Base(key),
+			key:      key,
+			path:     storagePath(storage, key),
+		})
+		return nil
+	}
+
+	// Download index.html
+	if err := addDocument(DocumentIndex, td.RawFilingURI, FilingFullSubmissionFilename); err != nil {
+		return nil, err
+	}
+
+	// Download primary document if available
+	if td.PrimaryDocURI != "" {
+		// Extract filename from primary document URI
+		primaryFileName := path.Base(td.PrimaryDocURI)
+		if err := addDocument(DocumentPrimary, td.PrimaryDocURI, primaryFileName); err != nil {
+			return nil, err
+		}
+	}
+
+	// Download details document if requested
+	if metadata.DownloadDetails && td.DetailsDocSuffix != "" {
+		// Calculate the details URL
+		rawAccNum := strings.ReplaceAll(td.AccessionNumber, "-", "")
+		detailsURL := endpoints.FilingURL(metadata.CIK, rawAccNum, rawAccNum+td.DetailsDocSuffix)
+		if err := addDocument(DocumentDetails, detailsURL, fmt.Sprintf("index%s", td.DetailsDocSuffix)); err != nil {
+			return nil, err
+		}
+	}
+
+	return docs, nil
+}
+
+// fetchDocument downloads a single document into the spool and returns its result.
+// The result's Key and Path are only set once the filing has been stored.
+func fetchDocument(ctx context.Context, client *SECClient, spool *filingSpool, doc filingDocument) DocumentResult {
+	result := DocumentResult{Kind: doc.kind, Name: doc.fileName, URL: doc.uri}
+
+	info, err := spool.download(ctx, client, doc.key, doc.uri)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.RetrievedAt = time.Now()
+
+	result.Bytes = info.Size
+	result.SHA256 = info.SHA256
+	result.LastModified = info.LastModified
+	result.ETag = info.ETag
+	return result
+}
+
+// documentMetadata returns the storage metadata of a downloaded document.
+func documentMetadata(metadata *DownloadMetadata, td ToDownload, doc DocumentResult) map[string]string {
+	objectMetadata := map[string]string{
+		MetadataSourceURL:       doc.URL,
+		MetadataAccessionNumber: td.AccessionNumber,
+		MetadataCIK:             metadata.CIK,
+		MetadataForm:            td.Form,
+		MetadataFilingDate:      td.FilingDate,
+		MetadataSHA256:          doc.SHA256,
+	}
+	if doc.ETag != "" {
+		objectMetadata[MetadataETag] = doc.ETag
+	}
+	if doc.LastModified != "" {
+		objectMetadata[MetadataLastModified] = doc.LastModified
+	}
+	return objectMetadata
 }
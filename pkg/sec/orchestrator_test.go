@@ -1,11 +1,33 @@
 package sec
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
+// newTestClient returns an SEC client whose www and data endpoints are both served by the test server.
+func newTestClient(t *testing.T, server *httptest.Server, options ...ClientOption) *SECClient {
+	t.Helper()
+
+	endpoints := DefaultEndpoints()
+	endpoints.WWWBaseURL = server.URL
+	endpoints.DataBaseURL = server.URL
+
+	return NewSECClient("TestCompany", "test@example.com", append([]ClientOption{WithEndpoints(endpoints)}, options...)...)
+}
+
 func TestGetSaveLocation(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -50,6 +72,30 @@ func TestGetSaveLocation(t *testing.T) {
 			saveFilename:    "primary-document.html",
 			want:            filepath.Join("/test/folder", RootSaveFolderName, "MSFT", "8-K", "0000789019-22-000001", "primary-document.html"),
 		},
+		{
+			name: "Amendment form is encoded",
+			metadata: &DownloadMetadata{
+				DownloadFolder: "/test/folder",
+				Form:           "10-K/A",
+				CIK:            "0000320193",
+				Ticker:         "AAPL",
+			},
+			accessionNumber: "0000320193-22-000002",
+			saveFilename:    "index.html",
+			want:            filepath.Join("/test/folder", RootSaveFolderName, "AAPL", "10-K%2FA", "0000320193-22-000002", "index.html"),
+		},
+		{
+			name: "Unsafe accession number",
+			metadata: &DownloadMetadata{
+				DownloadFolder: "/test/folder",
+				Form:           "10-K",
+				CIK:            "0000320193",
+				Ticker:         "AAPL",
+			},
+			accessionNumber: "../../etc",
+			saveFilename:    "index.html",
+			want:            "",
+		},
 	}
 
 	for _, tt := range tests {
@@ -58,10 +104,27 @@ func TestGetSaveLocation(t *testing.T) {
 			if got != tt.want {
 				t.Errorf("GetSaveLocation() = %v, want %v", got, tt.want)
 			}
+
+			// GetSaveKey reports why a path is rejected
+			var unsafeErr *UnsafePathError
+			_, err := GetSaveKey(tt.metadata, ToDownload{AccessionNumber: tt.accessionNumber}, tt.saveFilename)
+			if rejected := errors.As(err, &unsafeErr); rejected != (tt.want == "") {
+				t.Errorf("GetSaveKey() error = %v, want an UnsafePathError: %v", err, tt.want == "")
+			}
 		})
 	}
 }
 
+// savePath returns the location of a filing document in the download folder.
+func savePath(t *testing.T, metadata *DownloadMetadata, accessionNumber, saveFilename string) string {
+	t.Helper()
+	key, err := GetSaveKey(metadata, ToDownload{AccessionNumber: accessionNumber}, saveFilename)
+	if err != nil {
+		t.Fatalf("GetSaveKey() error = %v", err)
+	}
+	return filepath.Join(metadata.DownloadFolder, filepath.FromSlash(key))
+}
+
 func TestSaveDocument(t *testing.T) {
 	// Create temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "sec-test-*")
@@ -162,3 +225,567 @@ func TestGetToDownload(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateFilingsToDownloadPagination(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{
+			Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000001"},
+				FilingDate:      []string{"2022-10-28"},
+				Form:            []string{"10-K"},
+				PrimaryDocument: []string{"aapl-20220924.htm"},
+			},
+			Files: []SubmissionFile{
+				{Name: "CIK0000320193-submissions-002.json", FilingCount: 1, FilingFrom: "1994-01-01", FilingTo: "1999-12-31"},
+				{Name: "CIK0000320193-submissions-001.json", FilingCount: 2, FilingFrom: "2000-01-01", FilingTo: "2010-12-31"},
+			},
+		},
+	}
+	page := FilingColumns{
+		AccessionNumber: []string{"0000320193-08-000001", "0000320193-05-000001"},
+		FilingDate:      []string{"2008-11-05", "2005-12-01"},
+		Form:            []string{"10-K", "10-K"},
+		PrimaryDocument: []string{"d10k.htm", "d10k.htm"},
+	}
+
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+
+		switch r.URL.Path {
+		case "/submissions/CIK0000320193.json":
+			json.NewEncoder(w).Encode(submissions)
+		case "/submissions/CIK0000320193-submissions-001.json":
+			json.NewEncoder(w).Encode(page)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	tests := []struct {
+		name          string
+		after         time.Time
+		before        time.Time
+		limit         int
+		wantAccNums   []string
+		wantRequested []string
+	}{
+		{
+			name:        "Old date range loads only the overlapping page",
+			after:       time.Date(2004, 1, 1, 0, 0, 0, 0, time.UTC),
+			before:      time.Date(2006, 1, 1, 0, 0, 0, 0, time.UTC),
+			limit:       100,
+			wantAccNums: []string{"0000320193-05-000001"},
+			wantRequested: []string{
+				"/submissions/CIK0000320193.json",
+				"/submissions/CIK0000320193-submissions-001.json",
+			},
+		},
+		{
+			name:        "Limit satisfied by recent filings skips pages",
+			after:       DefaultAfterDate,
+			before:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			limit:       1,
+			wantAccNums: []string{"0000320193-22-000001"},
+			wantRequested: []string{
+				"/submissions/CIK0000320193.json",
+			},
+		},
+		{
+			name:        "Full history merges recent and paged filings newest first",
+			after:       DefaultAfterDate,
+			before:      time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			limit:       3,
+			wantAccNums: []string{"0000320193-22-000001", "0000320193-08-000001", "0000320193-05-000001"},
+			wantRequested: []string{
+				"/submissions/CIK0000320193.json",
+				"/submissions/CIK0000320193-submissions-001.json",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu.Lock()
+			requested = nil
+			mu.Unlock()
+
+			metadata := &DownloadMetadata{
+				Form:   "10-K",
+				CIK:    "0000320193",
+				Limit:  tt.limit,
+				After:  tt.after,
+				Before: tt.before,
+			}
+
+			toDownload, err := AggregateFilingsToDownload(metadata, client)
+			if err != nil {
+				t.Fatalf("AggregateFilingsToDownload() error = %v", err)
+			}
+
+			var gotAccNums []string
+			for _, td := range toDownload {
+				gotAccNums = append(gotAccNums, td.AccessionNumber)
+			}
+			if !slices.Equal(gotAccNums, tt.wantAccNums) {
+				t.Errorf("AggregateFilingsToDownload() accession numbers = %v, want %v", gotAccNums, tt.wantAccNums)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !slices.Equal(requested, tt.wantRequested) {
+				t.Errorf("AggregateFilingsToDownload() requested = %v, want %v", requested, tt.wantRequested)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsWithContextCancel(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{
+			Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000001", "0000320193-21-000001"},
+				FilingDate:      []string{"2022-10-28", "2021-10-29"},
+				Form:            []string{"10-K", "10-K"},
+				PrimaryDocument: []string{"aapl-20220924.htm", "aapl-20210925.htm"},
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/submissions/CIK0000320193.json":
+			json.NewEncoder(w).Encode(submissions)
+		case strings.HasSuffix(r.URL.Path, "-index.html"):
+			w.Write([]byte("<html>index</html>"))
+		default:
+			// Cancel while the primary document is in flight
+			cancel()
+			<-r.Context().Done()
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	metadata := &DownloadMetadata{
+		DownloadFolder: t.TempDir(),
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	report, err := FetchAndSaveFilingsWithContext(ctx, metadata, client)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("FetchAndSaveFilingsWithContext() error = %v, want context.Canceled", err)
+	}
+	if count := report.DownloadedCount(); count != 0 {
+		t.Errorf("FetchAndSaveFilingsWithContext() count = %d, want 0", count)
+	}
+	if len(report.Filings) != 2 {
+		t.Fatalf("FetchAndSaveFilingsWithContext() reported %d filings, want 2", len(report.Filings))
+	}
+	for _, filing := range report.Filings {
+		if !errors.Is(filing.Err, context.Canceled) {
+			t.Errorf("Filing %s error = %v, want context.Canceled", filing.AccessionNumber, filing.Err)
+		}
+	}
+
+	// The partially downloaded filing must have been cleaned up
+	filingDir := filepath.Dir(savePath(t, metadata, "0000320193-22-000001", FilingFullSubmissionFilename))
+	if _, err := os.Stat(filingDir); !os.IsNotExist(err) {
+		t.Errorf("Partially downloaded filing directory %s still exists (stat error = %v)", filingDir, err)
+	}
+}
+
+func TestFetchAndSaveFilingsConcurrency(t *testing.T) {
+	const filingCount = 6
+	const concurrency = 3
+
+	var recent FilingColumns
+	for i := 0; i < filingCount; i++ {
+		recent.AccessionNumber = append(recent.AccessionNumber, fmt.Sprintf("0000320193-22-%06d", i+1))
+		recent.FilingDate = append(recent.FilingDate, "2022-10-28")
+		recent.Form = append(recent.Form, "10-K")
+		recent.PrimaryDocument = append(recent.PrimaryDocument, "doc.htm")
+	}
+	submissions := SubmissionData{CIK: "320193", Filings: SubmissionFilings{Recent: recent}}
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.Write([]byte(r.URL.Path))
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com",
+		WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL, SubmissionsPath: DefaultSubmissionsPath}),
+		WithRateLimit(1000, 10),
+	)
+	metadata := &DownloadMetadata{
+		DownloadFolder: t.TempDir(),
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Limit:          filingCount,
+		After:          DefaultAfterDate,
+		Before:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		Concurrency:    concurrency,
+	}
+
+	report, err := FetchAndSaveFilings(metadata, client)
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+	if count := report.DownloadedCount(); count != filingCount {
+		t.Errorf("FetchAndSaveFilings() count = %d, want %d", count, filingCount)
+	}
+
+	// Results keep the order in which filings were selected
+	var gotAccNums []string
+	for _, filing := range report.Filings {
+		gotAccNums = append(gotAccNums, filing.AccessionNumber)
+	}
+	if !slices.Equal(gotAccNums, recent.AccessionNumber) {
+		t.Errorf("FetchAndSaveFilings() result order = %v, want %v", gotAccNums, recent.AccessionNumber)
+	}
+
+	if got := maxInFlight.Load(); got < 2 || got > concurrency {
+		t.Errorf("FetchAndSaveFilings() max concurrent requests = %d, want between 2 and %d", got, concurrency)
+	}
+
+	for _, accNum := range recent.AccessionNumber {
+		for _, name := range []string{FilingFullSubmissionFilename, "doc.htm"} {
+			if _, err := os.Stat(savePath(t, metadata, accNum, name)); err != nil {
+				t.Errorf("Expected %s of %s to be saved: %v", name, accNum, err)
+			}
+		}
+	}
+}
+
+func TestFetchAndSaveFilingsReport(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{
+			Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000001", "0000320193-21-000001", "0000320193-20-000001"},
+				FilingDate:      []string{"2022-10-28", "2021-10-29", "2020-10-30"},
+				Form:            []string{"10-K", "10-K", "10-K"},
+				PrimaryDocument: []string{"ok.htm", "missing.htm", "ok.htm"},
+			},
+		},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/submissions/CIK0000320193.json":
+			json.NewEncoder(w).Encode(submissions)
+		case strings.Contains(r.URL.Path, "000032019320000001"):
+			// The whole 2020 filing is gone
+			http.NotFound(w, r)
+		case strings.HasSuffix(r.URL.Path, "missing.htm"):
+			http.NotFound(w, r)
+		default:
+			w.Write([]byte("content"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+	metadata := &DownloadMetadata{
+		DownloadFolder: t.TempDir(),
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	report, err := FetchAndSaveFilings(metadata, client)
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("FetchAndSaveFilings() error = %v, want errors.Is(ErrNotFound)", err)
+	}
+	if report.CIK != "0000320193" || report.Ticker != "AAPL" {
+		t.Errorf("DownloadReport CIK/Ticker = %s/%s, want 0000320193/AAPL", report.CIK, report.Ticker)
+	}
+	if count := report.DownloadedCount(); count != 2 {
+		t.Errorf("DownloadedCount() = %d, want 2", count)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].AccessionNumber != "0000320193-20-000001" {
+		t.Errorf("Failed() = %v, want only 0000320193-20-000001", failed)
+	}
+
+	// Complete filing
+	complete := report.Filings[0]
+	if complete.Form != "10-K" || complete.FilingDate != "2022-10-28" {
+		t.Errorf("FilingResult Form/FilingDate = %s/%s, want 10-K/2022-10-28", complete.Form, complete.FilingDate)
+	}
+	if len(complete.Documents) != 2 || len(complete.SavedPaths()) != 2 {
+		t.Errorf("FilingResult documents = %+v, want 2 saved documents", complete.Documents)
+	}
+	for _, doc := range complete.Documents {
+		if doc.Bytes != int64(len("content")) {
+			t.Errorf("DocumentResult %s Bytes = %d, want %d", doc.Kind, doc.Bytes, len("content"))
+		}
+		if _, err := os.Stat(doc.Path); err != nil {
+			t.Errorf("DocumentResult %s Path %s not saved: %v", doc.Kind, doc.Path, err)
+		}
+	}
+
+	// Filing with a missing primary document still counts, but reports the document error
+	partial := report.Filings[1]
+	if !partial.Succeeded() {
+		t.Errorf("FilingResult %s Succeeded() = false, want true", partial.AccessionNumber)
+	}
+	if len(partial.Documents) != 2 || partial.Documents[1].Kind != DocumentPrimary || !errors.Is(partial.Documents[1].Err, ErrNotFound) {
+		t.Errorf("FilingResult %s documents = %+v, want failed primary document", partial.AccessionNumber, partial.Documents)
+	}
+	if len(partial.Errors()) != 1 {
+		t.Errorf("FilingResult %s Errors() = %v, want 1 error", partial.AccessionNumber, partial.Errors())
+	}
+
+	// The missing primary document is a warning, not a failure of the download
+	if warnings := report.Warnings(); len(warnings) != 1 || !errors.Is(warnings[0], ErrNotFound) {
+		t.Errorf("Warnings() = %v, want the missing primary document", warnings)
+	}
+	if strings.Contains(err.Error(), "missing.htm") {
+		t.Errorf("FetchAndSaveFilings() error = %v, want no optional document failure", err)
+	}
+	report.Filings = report.Filings[:2]
+	if err := report.Err(); err != nil {
+		t.Errorf("Err() without the failed filing = %v, want nil", err)
+	}
+}
+
+func TestFilterFilingsCustomFilters(t *testing.T) {
+	filings := &FilingColumns{
+		AccessionNumber: []string{"0000320193-23-000001", "0000320193-23-000002", "0000320193-23-000003"},
+		FilingDate:      []string{"2023-03-01", "2023-02-01", "2023-01-01"},
+		Form:            []string{"8-K", "8-K", "8-K"},
+		PrimaryDocument: []string{"a.htm", "b.txt", "c.htm"},
+		Items:           []string{"2.02,9.01", "5.02", "9.01"},
+	}
+
+	htmlOnly := func(f FilingInfo) bool { return strings.HasSuffix(f.PrimaryDocument, ".htm") }
+	withExhibits := func(f FilingInfo) bool { return slices.Contains(f.Items, "9.01") }
+	notFirst := func(f FilingInfo) bool { return f.AccessionNumber != "0000320193-23-000001" }
+
+	tests := []struct {
+		name        string
+		filters     []FilingFilter
+		items       []string
+		wantAccNums []string
+	}{
+		{
+			name:        "Single filter",
+			filters:     []FilingFilter{htmlOnly},
+			wantAccNums: []string{"0000320193-23-000001", "0000320193-23-000003"},
+		},
+		{
+			name:        "Filters are combined",
+			filters:     []FilingFilter{htmlOnly, notFirst},
+			wantAccNums: []string{"0000320193-23-000003"},
+		},
+		{
+			name:        "Filters compose with built-in filters",
+			filters:     []FilingFilter{withExhibits},
+			items:       []string{"2.02"},
+			wantAccNums: []string{"0000320193-23-000001"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &DownloadMetadata{
+				Form:    "8-K",
+				CIK:     "0000320193",
+				Limit:   10,
+				After:   DefaultAfterDate,
+				Before:  DefaultBeforeDate,
+				Items:   tt.items,
+				Filters: tt.filters,
+			}
+
+			toDownload, err := filterFilings(metadata, DefaultEndpoints(), filings, nil)
+			if err != nil {
+				t.Fatalf("filterFilings() error = %v", err)
+			}
+
+			var gotAccNums []string
+			for _, td := range toDownload {
+				gotAccNums = append(gotAccNums, td.AccessionNumber)
+			}
+			if !slices.Equal(gotAccNums, tt.wantAccNums) {
+				t.Errorf("filterFilings() accession numbers = %v, want %v", gotAccNums, tt.wantAccNums)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsSkipExisting(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{
+			Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000001", "0000320193-21-000001", "0000320193-20-000001"},
+				FilingDate:      []string{"2022-10-28", "2021-10-29", "2020-10-30"},
+				Form:            []string{"10-K", "10-K", "10-K"},
+				PrimaryDocument: []string{"a.htm", "b.htm", "c.htm"},
+			},
+		},
+	}
+
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(t, server)
+
+	tests := []struct {
+		name          string
+		options       []DownloadOption
+		wantRequested int
+		wantSkipped   []bool
+		wantCount     int
+		wantIndexKept bool
+	}{
+		{
+			name:          "Default downloads everything",
+			wantRequested: 6,
+			wantSkipped:   []bool{false, false, false},
+			wantCount:     3,
+		},
+		{
+			name:          "Skip existing downloads new and incomplete filings",
+			options:       []DownloadOption{WithSkipExisting(true)},
+			wantRequested: 4,
+			wantSkipped:   []bool{true, false, false},
+			wantCount:     2,
+		},
+		{
+			name:          "Repair downloads only missing documents",
+			options:       []DownloadOption{WithRepairMissing(true)},
+			wantRequested: 3,
+			wantSkipped:   []bool{true, false, false},
+			wantCount:     2,
+			wantIndexKept: true,
+		},
+		{
+			name:          "Force overrides skip and repair",
+			options:       []DownloadOption{WithSkipExisting(true), WithRepairMissing(true), WithForce(true)},
+			wantRequested: 6,
+			wantSkipped:   []bool{false, false, false},
+			wantCount:     3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata := &DownloadMetadata{
+				DownloadFolder: t.TempDir(),
+				Form:           "10-K",
+				CIK:            "0000320193",
+				Limit:          10,
+				After:          DefaultAfterDate,
+				Before:         DefaultBeforeDate,
+			}
+			for _, option := range tt.options {
+				option(metadata)
+			}
+
+			// The 2022 filing is complete, the 2021 filing lacks its primary document
+			for _, doc := range []struct{ accNum, name string }{
+				{"0000320193-22-000001", FilingFullSubmissionFilename},
+				{"0000320193-22-000001", "a.htm"},
+				{"0000320193-21-000001", FilingFullSubmissionFilename},
+			} {
+				if err := SaveDocument([]byte("existing"), savePath(t, metadata, doc.accNum, doc.name)); err != nil {
+					t.Fatalf("SaveDocument() error = %v", err)
+				}
+			}
+
+			mu.Lock()
+			requested = nil
+			mu.Unlock()
+
+			report, err := FetchAndSaveFilings(metadata, client)
+			if err != nil {
+				t.Fatalf("FetchAndSaveFilings() error = %v", err)
+			}
+
+			mu.Lock()
+			gotRequested := len(requested)
+			mu.Unlock()
+			if gotRequested != tt.wantRequested {
+				t.Errorf("FetchAndSaveFilings() downloaded %d documents, want %d", gotRequested, tt.wantRequested)
+			}
+
+			var gotSkipped []bool
+			for _, filing := range report.Filings {
+				gotSkipped = append(gotSkipped, filing.Skipped)
+			}
+			if !slices.Equal(gotSkipped, tt.wantSkipped) {
+				t.Errorf("FilingResult Skipped = %v, want %v", gotSkipped, tt.wantSkipped)
+			}
+			if got := report.DownloadedCount(); got != tt.wantCount {
+				t.Errorf("DownloadedCount() = %d, want %d", got, tt.wantCount)
+			}
+			if got := report.SkippedCount(); got != len(tt.wantSkipped)-tt.wantCount {
+				t.Errorf("SkippedCount() = %d, want %d", got, len(tt.wantSkipped)-tt.wantCount)
+			}
+
+			// Skipped documents are never reported as saved
+			for _, filing := range report.Filings {
+				for _, doc := range filing.Documents {
+					if doc.Skipped && slices.Contains(filing.SavedPaths(), doc.Path) {
+						t.Errorf("SavedPaths() contains skipped document %s", doc.Path)
+					}
+				}
+			}
+
+			// Repair leaves the existing documents of incomplete filings untouched
+			contents, err := os.ReadFile(savePath(t, metadata, "0000320193-21-000001", FilingFullSubmissionFilename))
+			if err != nil {
+				t.Fatalf("os.ReadFile() error = %v", err)
+			}
+			if (string(contents) == "existing") != tt.wantIndexKept {
+				t.Errorf("index of the incomplete filing = %q, want kept = %v", contents, tt.wantIndexKept)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package sec
+
+import (
+	"context"
+)
+
+// PlannedDocument describes a document that a download would fetch and save.
+type PlannedDocument struct {
+	// Kind is the role of the document within the filing
+	Kind DocumentKind
+	// Name is the file name the document would be saved as
+	Name string
+	// URL is the URL the document would be downloaded from
+	URL string
+	// Key is the storage key the document would be saved under
+	Key string
+	// Path is the location the document would be saved to (empty if the storage does not
+	// keep documents on the local file system)
+	Path string
+	// Exists reports whether the document already exists in the storage
+	Exists bool
+}
+
+// PlannedFiling describes a filing that a download would fetch.
+type PlannedFiling struct {
+	// Info contains the metadata of the filing from the submissions API
+	Info FilingInfo
+	// Documents lists the documents that would be downloaded for the filing
+	Documents []PlannedDocument
+	// Err is the error that would prevent the filing from being downloaded, e.g. an
+	// unsafe document name from the submissions API (see UnsafePathError)
+	Err error
+}
+
+// Exists reports whether every document of the filing already exists in the storage.
+func (f *PlannedFiling) Exists() bool {
+	for _, doc := range f.Documents {
+		if !doc.Exists {
+			return false
+		}
+	}
+	return len(f.Documents) > 0
+}
+
+// DownloadPlan describes what a download request would do, without doing it.
+// Filings are listed in the order in which they would be downloaded.
+type DownloadPlan struct {
+	// CIK is the Central Index Key of the company
+	CIK string
+	// Ticker is the stock ticker symbol if the plan was requested by ticker
+	Ticker string
+	// Filings lists every filing that would be downloaded
+	Filings []PlannedFiling
+}
+
+// ExistingCount returns the number of filings whose documents all exist in the storage.
+func (p *DownloadPlan) ExistingCount() int {
+	count := 0
+	for i := range p.Filings {
+		if p.Filings[i].Exists() {
+			count++
+		}
+	}
+	return count
+}
+
+// Plan reports which filings, URLs and paths a call to GetWithOptions with the same
+// arguments would touch. See PlanWithContext for details.
+//
+// Parameters:
+//   - form: Form type or form family to download (e.g., "8-K", "10-K", "annual reports")
+//   - tickerOrCIK: Ticker symbol or CIK for which to download filings
+//   - options: Variadic list of options to configure the download
+//
+// Returns:
+//   - The download plan and nil error on success
+//   - nil and error if the request is invalid or the filing list could not be fetched
+//
+// Example: Plan("10-K", "AAPL", WithLimit(5), WithDownloadDetails(true))
+func (d *Downloader) Plan(form string, tickerOrCIK string, options ...DownloadOption) (*DownloadPlan, error) {
+	return d.PlanWithContext(context.Background(), form, tickerOrCIK, options...)
+}
+
+// PlanWithContext reports which filings, URLs and paths a call to GetWithOptionsWithContext
+// with the same arguments would touch, and which of the documents already exist in the
+// storage. Only the company's submission history is fetched; no document is downloaded
+// and nothing is written to the storage.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - form: Form type or form family to download (e.g., "8-K", "10-K", "annual reports")
+//   - tickerOrCIK: Ticker symbol or CIK for which to download filings
+//   - options: Variadic list of options to configure the download
+//
+// Returns:
+//   - The download plan and nil error on success
+//   - nil and error if the request is invalid or the filing list could not be fetched
+func (d *Downloader) PlanWithContext(ctx context.Context, form string, tickerOrCIK string, options ...DownloadOption) (*DownloadPlan, error) {
+	// Build the download metadata
+	metadata, err := d.newDownloadMetadata(form, tickerOrCIK, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Get the filings that would be downloaded
+	toDownload, err := AggregateFilingsToDownloadWithContext(ctx, metadata, d.client)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &DownloadPlan{
+		CIK:     metadata.CIK,
+		Ticker:  metadata.Ticker,
+		Filings: make([]PlannedFiling, 0, len(toDownload)),
+	}
+	storage := storageOf(metadata)
+	for _, td := range toDownload {
+		filing := PlannedFiling{Info: td.Info}
+		docs, err := filingDocuments(metadata, d.client.endpoints, td)
+		if err != nil {
+			filing.Err = err
+		}
+		for _, doc := range docs {
+			exists, err := storage.Exists(ctx, doc.key)
+			if err != nil {
+				return nil, err
+			}
+			filing.Documents = append(filing.Documents, PlannedDocument{
+				Kind:   doc.kind,
+				Name:   doc.fileName,
+				URL:    doc.uri,
+				Key:    doc.key,
+				Path:   doc.path,
+				Exists: exists,
+			})
+		}
+		plan.Filings = append(plan.Filings, filing)
+	}
+
+	return plan, nil
+}
@@ -0,0 +1,111 @@
+package sec
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDownloaderPlan(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000002", "0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28", "2021-10-29"},
+			Form:            []string{"10-K", "10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm", "aapl-20210925.htm"},
+			Size:            []int64{1000, 2000},
+		}},
+	}
+
+	var documentRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		documentRequests.Add(1)
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	downloader := &Downloader{
+		client:         newTestClient(t, server),
+		downloadFolder: folder,
+		tickerToCIKMap: map[string]string{"AAPL": "0000320193"},
+	}
+
+	// The older filing was already downloaded
+	metadata := &DownloadMetadata{DownloadFolder: folder, Form: "10-K", CIK: "0000320193", Ticker: "AAPL"}
+	for _, name := range []string{FilingFullSubmissionFilename, "aapl-20210925.htm"} {
+		if err := SaveDocument([]byte("content"), savePath(t, metadata, "0000320193-22-000001", name)); err != nil {
+			t.Fatalf("SaveDocument() error = %v", err)
+		}
+	}
+	plan, err := downloader.Plan("10-K", "AAPL", WithDownloadDetails(true))
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+
+	if documentRequests.Load() != 0 {
+		t.Errorf("Plan() downloaded %d documents, want 0", documentRequests.Load())
+	}
+	if plan.CIK != "0000320193" || plan.Ticker != "AAPL" {
+		t.Errorf("Plan() CIK = %q, Ticker = %q", plan.CIK, plan.Ticker)
+	}
+	if len(plan.Filings) != 2 {
+		t.Fatalf("Plan() planned %d filings, want 2", len(plan.Filings))
+	}
+
+	newest := plan.Filings[0]
+	if newest.Info.AccessionNumber != "0000320193-22-000002" || newest.Info.Size != 1000 {
+		t.Errorf("Plan() first filing = %+v", newest.Info)
+	}
+	wantKinds := []DocumentKind{DocumentIndex, DocumentPrimary, DocumentDetails}
+	if len(newest.Documents) != len(wantKinds) {
+		t.Fatalf("Plan() planned %d documents, want %d", len(newest.Documents), len(wantKinds))
+	}
+	for i, doc := range newest.Documents {
+		if doc.Kind != wantKinds[i] {
+			t.Errorf("Plan() document %d kind = %v, want %v", i, doc.Kind, wantKinds[i])
+		}
+		if doc.URL == "" || doc.Exists {
+			t.Errorf("Plan() document %d = %+v, want a URL and no file", i, doc)
+		}
+	}
+	wantPath := filepath.Join(folder, RootSaveFolderName, "AAPL", "10-K", "0000320193-22-000002", "aapl-20220924.htm")
+	if newest.Documents[1].Path != wantPath {
+		t.Errorf("Plan() primary document path = %q, want %q", newest.Documents[1].Path, wantPath)
+	}
+	if newest.Documents[1].URL != server.URL+"/Archives/edgar/data/0000320193/000032019322000002/aapl-20220924.htm" {
+		t.Errorf("Plan() primary document URL = %q", newest.Documents[1].URL)
+	}
+
+	// The details document of the older filing is missing
+	older := plan.Filings[1]
+	if !older.Documents[0].Exists || !older.Documents[1].Exists || older.Documents[2].Exists {
+		t.Errorf("Plan() older filing documents = %+v, want index and primary to exist", older.Documents)
+	}
+	if older.Exists() || plan.ExistingCount() != 0 {
+		t.Errorf("Plan() reports the partially downloaded filing as existing")
+	}
+
+	// Without details, the older filing is complete
+	plan, err = downloader.Plan("10-K", "AAPL")
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if plan.ExistingCount() != 1 || !plan.Filings[1].Exists() {
+		t.Errorf("Plan() ExistingCount() = %d, want 1", plan.ExistingCount())
+	}
+
+	// Nothing was written for the newest filing
+	if _, err := os.Stat(filepath.Dir(wantPath)); !os.IsNotExist(err) {
+		t.Errorf("Plan() created %s", filepath.Dir(wantPath))
+	}
+}
@@ -0,0 +1,124 @@
+package sec
+
+import (
+	"context"
+	"fmt"
+)
+
+// Address represents a business or mailing address of a company.
+type Address struct {
+	// Street1 is the first line of the street address
+	Street1 string `json:"street1"`
+	// Street2 is the second line of the street address (may be empty)
+	Street2 string `json:"street2"`
+	// City is the city of the address
+	City string `json:"city"`
+	// StateOrCountry is the EDGAR state or country code (e.g., "CA")
+	StateOrCountry string `json:"stateOrCountry"`
+	// StateOrCountryDescription is the name of the state or country
+	StateOrCountryDescription string `json:"stateOrCountryDescription"`
+	// ZipCode is the postal code of the address
+	ZipCode string `json:"zipCode"`
+}
+
+// CompanyAddresses holds the addresses a company reports to the SEC.
+type CompanyAddresses struct {
+	// Mailing is the mailing address of the company
+	Mailing Address `json:"mailing"`
+	// Business is the business address of the company
+	Business Address `json:"business"`
+}
+
+// FormerName represents a name previously used by a company.
+type FormerName struct {
+	// Name is the former name of the company
+	Name string `json:"name"`
+	// From is the time from which the name was used (e.g., "2007-01-04T00:00:00.000Z")
+	From string `json:"from"`
+	// To is the time until which the name was used
+	To string `json:"to"`
+}
+
+// CompanyProfile represents the company information included in the submissions file
+// of a company, alongside its filings.
+type CompanyProfile struct {
+	// CIK is the Central Index Key that identifies the company
+	CIK string `json:"cik"`
+	// Name is the name of the company
+	Name string `json:"name"`
+	// EntityType is the type of entity (e.g., "operating")
+	EntityType string `json:"entityType"`
+	// SIC is the Standard Industrial Classification code of the company
+	SIC string `json:"sic"`
+	// SICDescription is the description of the SIC code
+	SICDescription string `json:"sicDescription"`
+	// Description is the description of the company (often empty)
+	Description string `json:"description"`
+	// StateOfIncorporation is the EDGAR code of the state or country of incorporation (e.g., "CA")
+	StateOfIncorporation string `json:"stateOfIncorporation"`
+	// StateOfIncorporationDescription is the name of the state or country of incorporation
+	StateOfIncorporationDescription string `json:"stateOfIncorporationDescription"`
+	// FiscalYearEnd is the end of the fiscal year in "MMDD" format (e.g., "0928")
+	FiscalYearEnd string `json:"fiscalYearEnd"`
+	// Addresses contains the business and mailing addresses of the company
+	Addresses CompanyAddresses `json:"addresses"`
+	// Phone is the phone number of the company
+	Phone string `json:"phone"`
+	// Tickers lists the ticker symbols of the company
+	Tickers []string `json:"tickers"`
+	// Exchanges lists the exchanges on which the tickers are listed, in the same order
+	Exchanges []string `json:"exchanges"`
+	// EIN is the Employer Identification Number of the company
+	EIN string `json:"ein"`
+	// FormerNames lists the names previously used by the company
+	FormerNames []FormerName `json:"formerNames"`
+}
+
+// Profile returns the company profile of the submission data.
+func (d *SubmissionData) Profile() CompanyProfile {
+	profile := d.CompanyProfile
+	profile.CIK = d.CIK
+	return profile
+}
+
+// GetCompanyProfile returns the profile of a company.
+// See GetCompanyProfileWithContext for details.
+//
+// Parameters:
+//   - tickerOrCIK: Ticker symbol or CIK of the company
+//
+// Returns:
+//   - The company profile and nil error on success
+//   - nil and error on failure
+//
+// Example: GetCompanyProfile("AAPL")
+func (d *Downloader) GetCompanyProfile(tickerOrCIK string) (*CompanyProfile, error) {
+	return d.GetCompanyProfileWithContext(context.Background(), tickerOrCIK)
+}
+
+// GetCompanyProfileWithContext returns the profile of a company.
+// The profile is read from the same submissions file that lists the company's filings.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - tickerOrCIK: Ticker symbol or CIK of the company
+//
+// Returns:
+//   - The company profile and nil error on success
+//   - nil and error on failure
+func (d *Downloader) GetCompanyProfileWithContext(ctx context.Context, tickerOrCIK string) (*CompanyProfile, error) {
+	// Validate and convert the ticker or CIK
+	cik, err := ValidateAndConvertTickerOrCIK(tickerOrCIK, d.tickerToCIKMap)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ticker or CIK: %w", err)
+	}
+
+	// Get the submissions file of the company
+	data, err := newSubmissionSource(d.client, cik).submissions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := data.Profile()
+	return &profile, nil
+}
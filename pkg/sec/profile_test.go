@@ -0,0 +1,111 @@
+package sec
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// testSubmissionsWithProfile is an excerpt of a submissions file, including the company information.
+const testSubmissionsWithProfile = `{
+	"cik": "320193",
+	"entityType": "operating",
+	"sic": "3571",
+	"sicDescription": "Electronic Computers",
+	"name": "Apple Inc.",
+	"tickers": ["AAPL"],
+	"exchanges": ["Nasdaq"],
+	"ein": "942404110",
+	"description": "",
+	"fiscalYearEnd": "0928",
+	"stateOfIncorporation": "CA",
+	"stateOfIncorporationDescription": "CA",
+	"addresses": {
+		"mailing": {"street1": "ONE APPLE PARK WAY", "street2": null, "city": "CUPERTINO", "stateOrCountry": "CA", "zipCode": "95014", "stateOrCountryDescription": "CA"},
+		"business": {"street1": "ONE APPLE PARK WAY", "street2": null, "city": "CUPERTINO", "stateOrCountry": "CA", "zipCode": "95014", "stateOrCountryDescription": "CA"}
+	},
+	"phone": "(408) 996-1010",
+	"formerNames": [
+		{"name": "APPLE INC", "from": "2007-01-10T00:00:00.000Z", "to": "2019-08-05T00:00:00.000Z"},
+		{"name": "APPLE COMPUTER INC", "from": "1994-01-26T00:00:00.000Z", "to": "2007-01-04T00:00:00.000Z"}
+	],
+	"filings": {
+		"recent": {
+			"accessionNumber": ["0000320193-23-000106"],
+			"filingDate": ["2023-11-03"],
+			"form": ["10-K"],
+			"primaryDocument": ["aapl-20230930.htm"]
+		},
+		"files": []
+	}
+}`
+
+func TestDownloaderGetCompanyProfile(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DefaultSubmissionsPath+"/CIK0000320193.json" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(testSubmissionsWithProfile))
+	}))
+	defer server.Close()
+
+	downloader := &Downloader{
+		client:         newTestClient(t, server),
+		downloadFolder: t.TempDir(),
+		tickerToCIKMap: map[string]string{"AAPL": "0000320193", "MSFT": "0000789019"},
+	}
+
+	address := Address{
+		Street1:                   "ONE APPLE PARK WAY",
+		City:                      "CUPERTINO",
+		StateOrCountry:            "CA",
+		StateOrCountryDescription: "CA",
+		ZipCode:                   "95014",
+	}
+	want := &CompanyProfile{
+		CIK:                             "320193",
+		Name:                            "Apple Inc.",
+		EntityType:                      "operating",
+		SIC:                             "3571",
+		SICDescription:                  "Electronic Computers",
+		StateOfIncorporation:            "CA",
+		StateOfIncorporationDescription: "CA",
+		FiscalYearEnd:                   "0928",
+		Addresses:                       CompanyAddresses{Mailing: address, Business: address},
+		Phone:                           "(408) 996-1010",
+		Tickers:                         []string{"AAPL"},
+		Exchanges:                       []string{"Nasdaq"},
+		EIN:                             "942404110",
+		FormerNames: []FormerName{
+			{Name: "APPLE INC", From: "2007-01-10T00:00:00.000Z", To: "2019-08-05T00:00:00.000Z"},
+			{Name: "APPLE COMPUTER INC", From: "1994-01-26T00:00:00.000Z", To: "2007-01-04T00:00:00.000Z"},
+		},
+	}
+
+	for _, tickerOrCIK := range []string{"AAPL", "320193"} {
+		t.Run(tickerOrCIK, func(t *testing.T) {
+			got, err := downloader.GetCompanyProfile(tickerOrCIK)
+			if err != nil {
+				t.Fatalf("GetCompanyProfile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GetCompanyProfile() = %+v, want %+v", got, want)
+			}
+		})
+	}
+
+	t.Run("Unknown company", func(t *testing.T) {
+		if _, err := downloader.GetCompanyProfile("MSFT"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("GetCompanyProfile() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Invalid ticker", func(t *testing.T) {
+		if _, err := downloader.GetCompanyProfile("INVALID"); err == nil {
+			t.Errorf("GetCompanyProfile() succeeded, want error")
+		}
+	})
+}
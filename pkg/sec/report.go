@@ -0,0 +1,193 @@
+package sec
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DocumentKind identifies the role of a document within a filing.
+type DocumentKind string
+
+const (
+	// DocumentIndex is the filing index page listing all documents of the filing
+	DocumentIndex DocumentKind = "index"
+	// DocumentPrimary is the primary document of the filing
+	DocumentPrimary DocumentKind = "primary"
+	// DocumentDetails is the filing details document
+	DocumentDetails DocumentKind = "details"
+)
+
+// DocumentResult describes the outcome of downloading a single document of a filing.
+type DocumentResult struct {
+	// Kind is the role of the document within the filing
+	Kind DocumentKind
+	// Name is the file name the document is saved as
+	Name string
+	// URL is the URL the document was downloaded from
+	URL string
+	// Key is the storage key the document was saved under (empty if it was not saved)
+	Key string
+	// Path is the location the document was saved to (empty if it was not saved or the
+	// storage does not keep documents on the local file system)
+	Path string
+	// Bytes is the number of bytes saved
+	Bytes int64
+	// SHA256 is the hex-encoded SHA-256 checksum of the saved document
+	SHA256 string
+	// LastModified is the Last-Modified header returned by the SEC (may be empty)
+	LastModified string
+	// ETag is the ETag header returned by the SEC (may be empty)
+	ETag string
+	// RetrievedAt is the time when the document was downloaded
+	RetrievedAt time.Time
+	// Skipped reports whether the document was not downloaded because it already exists in the storage
+	Skipped bool
+	// Err is the error that prevented the document from being saved, if any
+	Err error
+}
+
+// FilingResult describes the outcome of downloading a single filing.
+type FilingResult struct {
+	// AccessionNumber is the unique identifier for the filing
+	AccessionNumber string
+	// Form is the SEC form type of the filing
+	Form string
+	// BaseForm is the form amended by the filing if it is an amendment (e.g., "10-K" for
+	// "10-K/A"), or Form otherwise
+	BaseForm string
+	// IsAmendment reports whether the filing amends an earlier filing of BaseForm
+	IsAmendment bool
+	// FilingDate is the date when the filing was submitted in "YYYY-MM-DD" format
+	FilingDate string
+	// Items lists the item codes reported by the filing (e.g., for 8-K filings)
+	Items []string
+	// Documents lists the outcome of every document attempted for the filing
+	Documents []DocumentResult
+	// Duration is the time spent downloading and saving the filing
+	Duration time.Duration
+	// Skipped reports whether the filing was not downloaded because all of its
+	// documents already exist in the storage (see WithSkipExisting)
+	Skipped bool
+	// Err is the error that prevented the filing from being downloaded, if any.
+	// Failures of optional documents are reported in Documents instead.
+	Err error
+}
+
+// Succeeded reports whether the filing was downloaded or skipped because it already
+// exists in the storage. Optional documents may still have failed; see Documents.
+func (r *FilingResult) Succeeded() bool {
+	return r.Err == nil
+}
+
+// SavedPaths returns the locations of all documents saved for the filing.
+// Documents skipped because they already exist in the storage are not included.
+func (r *FilingResult) SavedPaths() []string {
+	var paths []string
+	for _, doc := range r.Documents {
+		if doc.Err == nil && doc.Path != "" && !doc.Skipped {
+			paths = append(paths, doc.Path)
+		}
+	}
+	return paths
+}
+
+// Errors returns the filing error and the errors of its documents,
+// annotated with the accession number and document kind. See Warnings for the
+// failures of optional documents alone.
+func (r *FilingResult) Errors() []error {
+	var errs []error
+	if r.Err != nil {
+		errs = append(errs, fmt.Errorf("filing %s: %w", r.AccessionNumber, r.Err))
+	}
+	for _, doc := range r.Documents {
+		if doc.Err != nil && doc.Err != r.Err {
+			errs = append(errs, fmt.Errorf("filing %s: %s document %s: %w", r.AccessionNumber, doc.Kind, doc.Name, doc.Err))
+		}
+	}
+	return errs
+}
+
+// Warnings returns the errors of the optional documents that could not be saved although
+// the filing was downloaded, annotated with the accession number and document kind.
+func (r *FilingResult) Warnings() []error {
+	if r.Err != nil {
+		return nil
+	}
+	var warnings []error
+	for _, doc := range r.Documents {
+		if doc.Err != nil {
+			warnings = append(warnings, fmt.Errorf("filing %s: %s document %s: %w", r.AccessionNumber, doc.Kind, doc.Name, doc.Err))
+		}
+	}
+	return warnings
+}
+
+// DownloadReport describes the outcome of a download request.
+// Filings are listed in the order in which they were selected for download.
+type DownloadReport struct {
+	// CIK is the Central Index Key of the company
+	CIK string
+	// Ticker is the stock ticker symbol if the download was requested by ticker
+	Ticker string
+	// Filings lists the outcome of every filing selected for download
+	Filings []FilingResult
+}
+
+// DownloadedCount returns the number of filings that were downloaded.
+// Filings skipped because they already exist in the storage are not counted.
+func (r *DownloadReport) DownloadedCount() int {
+	count := 0
+	for i := range r.Filings {
+		if r.Filings[i].Succeeded() && !r.Filings[i].Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// SkippedCount returns the number of filings skipped because they already exist in the storage.
+func (r *DownloadReport) SkippedCount() int {
+	count := 0
+	for i := range r.Filings {
+		if r.Filings[i].Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns the filings that could not be downloaded.
+func (r *DownloadReport) Failed() []FilingResult {
+	var failed []FilingResult
+	for _, filing := range r.Filings {
+		if !filing.Succeeded() {
+			failed = append(failed, filing)
+		}
+	}
+	return failed
+}
+
+// Err returns an error joining the errors of every filing that could not be downloaded,
+// or nil if every filing was downloaded or skipped. Failures of optional documents of
+// downloaded filings are not included; see Warnings. The individual errors can be
+// inspected with errors.Is and errors.As.
+func (r *DownloadReport) Err() error {
+	var errs []error
+	for i := range r.Filings {
+		if err := r.Filings[i].Err; err != nil {
+			errs = append(errs, fmt.Errorf("filing %s: %w", r.Filings[i].AccessionNumber, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Warnings returns the failures of optional documents of the filings that were
+// downloaded, such as a missing details document.
+func (r *DownloadReport) Warnings() []error {
+	var warnings []error
+	for i := range r.Filings {
+		warnings = append(warnings, r.Filings[i].Warnings()...)
+	}
+	return warnings
+}
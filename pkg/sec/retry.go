@@ -0,0 +1,153 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy configures how the SEC client retries failed requests.
+// Every attempt goes through the client's rate limiter, so retries never exceed
+// the SEC's request rate.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per request, including the first one.
+	// Values less than or equal to 1 disable retries.
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry; it doubles with every further attempt
+	BaseBackoff time.Duration
+	// MaxBackoff caps every delay between attempts, including jitter and Retry-After hints
+	MaxBackoff time.Duration
+	// Jitter is the fraction (between 0 and 1) by which each backoff is randomly varied
+	Jitter float64
+	// RespectRetryAfter determines whether a Retry-After header sent by the SEC extends the backoff,
+	// up to MaxBackoff
+	RespectRetryAfter bool
+}
+
+// DefaultRetryPolicy is the retry policy used by new SEC clients.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:       5,
+	BaseBackoff:       500 * time.Millisecond,
+	MaxBackoff:        30 * time.Second,
+	Jitter:            0.2,
+	RespectRetryAfter: true,
+}
+
+// retryableStatusCodes are the HTTP status codes that indicate a transient failure.
+var retryableStatusCodes = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// attempts returns the number of attempts allowed by the policy.
+func (p RetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// backoff returns the delay to wait before the given retry attempt (starting at 1).
+// A positive retryAfter hint from the server is honored if the policy allows it. The
+// delay, including jitter and any hint, never exceeds MaxBackoff, so that a server
+// cannot stall the client indefinitely.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) time.Duration {
+	delay := p.BaseBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	// Randomly vary the delay so that concurrent clients do not retry in lockstep
+	if p.Jitter > 0 && delay > 0 {
+		jitter := min(p.Jitter, 1)
+		delay = time.Duration(float64(delay) * (1 + jitter*(2*rand.Float64()-1)))
+	}
+
+	if p.RespectRetryAfter && retryAfter > delay {
+		delay = retryAfter
+	}
+
+	// Cap the final delay
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	return delay
+}
+
+// isRetryableError reports whether a failed request should be attempted again.
+// Timeouts, including the http.Client timeout (which matches context.DeadlineExceeded),
+// are retryable; callSECWithHeader stops on its own when the caller's context is done.
+func isRetryableError(err error) bool {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return retryableStatusCodes[httpErr.StatusCode] || errors.Is(httpErr, ErrRateLimited)
+	}
+
+	// Cancellation by the caller, and a deadline the rate limiter cannot meet, are never retried
+	if errors.Is(err, context.Canceled) || errors.Is(err, errLimiterDeadline) {
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date. It returns zero if the value is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if wait := date.Sub(now); wait > 0 {
+			return wait
+		}
+	}
+
+	return 0
+}
+
+// sleepContext waits for the given duration or until the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,314 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fastRetryPolicy retries quickly so that tests do not have to wait.
+var fastRetryPolicy = RetryPolicy{
+	MaxAttempts:       3,
+	BaseBackoff:       time.Millisecond,
+	MaxBackoff:        5 * time.Millisecond,
+	RespectRetryAfter: true,
+}
+
+func TestCallSECRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantAttempts int32
+		wantErr      bool
+	}{
+		{
+			name:         "Succeeds after transient errors",
+			statuses:     []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK},
+			wantAttempts: 3,
+			wantErr:      false,
+		},
+		{
+			name:         "Gives up after max attempts",
+			statuses:     []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusOK},
+			wantAttempts: 3,
+			wantErr:      true,
+		},
+		{
+			name:         "Does not retry permanent errors",
+			statuses:     []int{http.StatusNotFound, http.StatusOK},
+			wantAttempts: 1,
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				status := tt.statuses[min(int(n), len(tt.statuses))-1]
+				if status == http.StatusTooManyRequests {
+					w.Header().Set("Retry-After", "0")
+				}
+				w.WriteHeader(status)
+				w.Write([]byte("content"))
+			}))
+			defer server.Close()
+
+			client := NewSECClient("TestCompany", "test@example.com")
+			client.SetRetryPolicy(fastRetryPolicy)
+
+			_, err := client.DownloadFiling(server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DownloadFiling() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("DownloadFiling() made %d attempts, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		BaseBackoff:       100 * time.Millisecond,
+		MaxBackoff:        time.Second,
+		RespectRetryAfter: true,
+	}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{name: "First retry", attempt: 1, want: 100 * time.Millisecond},
+		{name: "Exponential growth", attempt: 3, want: 400 * time.Millisecond},
+		{name: "Capped at max backoff", attempt: 10, want: time.Second},
+		{name: "Retry-After extends backoff", attempt: 1, retryAfter: 500 * time.Millisecond, want: 500 * time.Millisecond},
+		{name: "Retry-After capped at max backoff", attempt: 1, retryAfter: 5 * time.Second, want: time.Second},
+		{name: "Shorter Retry-After is ignored", attempt: 3, retryAfter: time.Millisecond, want: 400 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.backoff(tt.attempt, tt.retryAfter); got != tt.want {
+				t.Errorf("backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Jitter keeps the delay within the configured fraction
+	policy.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		got := policy.backoff(1, 0)
+		if got < 50*time.Millisecond || got > 150*time.Millisecond {
+			t.Fatalf("backoff() with jitter = %v, want between 50ms and 150ms", got)
+		}
+	}
+
+	// Jitter never pushes the delay beyond the max backoff
+	for i := 0; i < 100; i++ {
+		if got := policy.backoff(10, 0); got > policy.MaxBackoff {
+			t.Fatalf("backoff() with jitter = %v, want at most %v", got, policy.MaxBackoff)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Empty", value: "", want: 0},
+		{name: "Seconds", value: "120", want: 2 * time.Minute},
+		{name: "Negative seconds", value: "-5", want: 0},
+		{name: "HTTP date", value: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{name: "Past HTTP date", value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+		{name: "Invalid", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	// A request that exceeds the http.Client timeout
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+	_, timeoutErr := (&http.Client{Timeout: 10 * time.Millisecond}).Get(slow.URL)
+	if timeoutErr == nil {
+		t.Fatalf("Get() of a slow server succeeded, want a timeout")
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Service unavailable", err: &HTTPError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "Not found", err: &HTTPError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "Connection reset", err: syscall.ECONNRESET, want: true},
+		{name: "Unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "Client timeout", err: timeoutErr, want: true},
+		{name: "Cancellation", err: context.Canceled, want: false},
+		{name: "Other error", err: errors.New("boom"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryableError(tt.err); got != tt.want {
+				t.Errorf("isRetryableError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryClientTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first attempt outlasts the client timeout
+		if attempts.Add(1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com", WithTimeout(50*time.Millisecond), WithRetryPolicy(fastRetryPolicy))
+	content, err := client.DownloadFiling(server.URL)
+	if err != nil {
+		t.Fatalf("DownloadFiling() error = %v", err)
+	}
+	if string(content) != "content" || attempts.Load() != 2 {
+		t.Errorf("DownloadFiling() = %q after %d attempts, want %q after 2", content, attempts.Load(), "content")
+	}
+}
+
+func TestRetryInterruptedBody(t *testing.T) {
+	document := strings.Repeat("document body ", 1000)
+	submissions := `{"cik":"320193","filings":{"recent":{"accessionNumber":["0000320193-22-000001"],"filingDate":["2022-10-28"],"form":["10-K"]}}}`
+
+	tests := []struct {
+		name string
+		// content is served with Range support; the first response is cut in half
+		content    string
+		etag       string
+		download   func(client *SECClient, uri string) (string, error)
+		wantRanges int32
+		wantErr    bool
+	}{
+		{
+			name:    "Download is resumed",
+			content: document,
+			etag:    `"v1"`,
+			download: func(client *SECClient, uri string) (string, error) {
+				content, err := client.DownloadFiling(uri)
+				return string(content), err
+			},
+			wantRanges: 1,
+		},
+		{
+			name:    "Download without validator is not spliced",
+			content: document,
+			download: func(client *SECClient, uri string) (string, error) {
+				content, err := client.DownloadFiling(uri)
+				return string(content), err
+			},
+			wantErr: true,
+		},
+		{
+			name:    "JSON is requested again",
+			content: submissions,
+			download: func(client *SECClient, uri string) (string, error) {
+				data, err := client.GetListOfAvailableFilings(uri)
+				if err != nil || data.Filings.Recent.AccessionNumber[0] != "0000320193-22-000001" {
+					return "", err
+				}
+				return submissions, nil
+			},
+		},
+		{
+			name:    "Spooled document is resumed",
+			content: document,
+			etag:    `"v1"`,
+			download: func(client *SECClient, uri string) (string, error) {
+				file, err := os.CreateTemp(t.TempDir(), "spool")
+				if err != nil {
+					return "", err
+				}
+				defer file.Close()
+				if _, err := client.ResumeFilingTo(file, uri, DocumentInfo{}); err != nil {
+					return "", err
+				}
+				content, err := os.ReadFile(file.Name())
+				return string(content), err
+			},
+			wantRanges: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts, ranges atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if r.Header.Get("Range") != "" {
+					ranges.Add(1)
+				}
+				// The connection is lost half way through the first response
+				if attempts.Add(1) == 1 {
+					w.Header().Set("Content-Length", strconv.Itoa(len(tt.content)))
+					w.Write([]byte(tt.content[:len(tt.content)/2]))
+					return
+				}
+				http.ServeContent(w, r, "", time.Time{}, strings.NewReader(tt.content))
+			}))
+			defer server.Close()
+
+			client := NewSECClient("TestCompany", "test@example.com", WithRetryPolicy(fastRetryPolicy))
+			content, err := tt.download(client, server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("download error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && content != tt.content {
+				t.Errorf("download = %d bytes, want %d", len(content), len(tt.content))
+			}
+			if got := ranges.Load(); got != tt.wantRanges {
+				t.Errorf("download made %d range requests, want %d", got, tt.wantRanges)
+			}
+			if wantAttempts := int32(2); !tt.wantErr && attempts.Load() != wantAttempts {
+				t.Errorf("download made %d requests, want %d", attempts.Load(), wantAttempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package sec
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// maxPathComponentLength is the maximum length in bytes of a file or directory name
+// on common file systems.
+const maxPathComponentLength = 255
+
+// accessionNumberPattern matches accession numbers with or without dashes
+// (e.g., "0000320193-22-000108" or "000032019322000108").
+var accessionNumberPattern = regexp.MustCompile(`^(\d{10}-\d{2}-\d{6}|\d{18})$`)
+
+// reservedNameCharacters replaces the characters that Windows does not allow in file names.
+var reservedNameCharacters = strings.NewReplacer(
+	"<", "_", ">", "_", ":", "_", "\"", "_", "|", "_", "?", "_", "*", "_",
+)
+
+// reservedDeviceNames lists the names that Windows reserves for devices, with or without extension.
+var reservedDeviceNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizePathComponent checks that an externally supplied value can be used as a single
+// file or directory name and returns it in a form usable on every platform.
+// Values that could escape their directory (separators, "." and "..") or that contain
+// control characters are rejected; characters reserved on Windows are replaced by "_",
+// trailing dots and spaces are removed, and reserved device names are prefixed with "_".
+func sanitizePathComponent(kind, value string) (string, error) {
+	if reason := unsafeNameReason(value); reason != "" {
+		return "", &UnsafePathError{Kind: kind, Value: value, Reason: reason}
+	}
+
+	sanitized := strings.TrimRight(reservedNameCharacters.Replace(value), ". ")
+	if sanitized == "" {
+		return "", &UnsafePathError{Kind: kind, Value: value, Reason: "consists of dots and spaces"}
+	}
+	stem, _, _ := strings.Cut(sanitized, ".")
+	if reservedDeviceNames[strings.ToUpper(strings.TrimSpace(stem))] {
+		sanitized = "_" + sanitized
+	}
+	return sanitized, nil
+}
+
+// unsafeNameReason returns why a value cannot be used as a file or directory name,
+// or an empty string if it can.
+func unsafeNameReason(value string) string {
+	switch {
+	case value == "":
+		return "empty"
+	case value == "." || value == "..":
+		return "refers to a directory"
+	case strings.ContainsAny(value, "/\\"):
+		return "contains a path separator"
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		return "contains a control character"
+	case len(value) > maxPathComponentLength:
+		return "too long"
+	}
+	return ""
+}
+
+// validateDocumentName checks a document name from the submissions API, which is a
+// path relative to the filing's directory in the EDGAR archive (e.g., "aapl-20220924.htm"
+// or "xslF345X05/wk-form4.xml"). Every segment must be a safe name, and URL delimiters
+// and escapes are rejected, so that the document URL stays within the filing's directory.
+func validateDocumentName(name string) error {
+	if strings.HasPrefix(name, "/") {
+		return &UnsafePathError{Kind: "document name", Value: name, Reason: "must be relative"}
+	}
+	if strings.ContainsAny(name, "?#%") {
+		return &UnsafePathError{Kind: "document name", Value: name, Reason: "contains a URL delimiter or escape"}
+	}
+	for _, segment := range strings.Split(name, "/") {
+		if reason := unsafeNameReason(segment); reason != "" {
+			return &UnsafePathError{Kind: "document name", Value: name, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// validateAccessionNumber checks that an accession number consists of 18 digits,
+// optionally with dashes in the standard positions.
+func validateAccessionNumber(accessionNumber string) error {
+	if !accessionNumberPattern.MatchString(accessionNumber) {
+		return &UnsafePathError{Kind: "accession number", Value: accessionNumber, Reason: "must be 18 digits, as in 0000320193-22-000108"}
+	}
+	return nil
+}
+
+// validateCIK checks that a CIK consists of digits only.
+func validateCIK(cik string) error {
+	if cik == "" || strings.IndexFunc(cik, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+		return &UnsafePathError{Kind: "CIK", Value: cik, Reason: "must consist of digits"}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package sec
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSanitizePathComponent(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{name: "Plain name", value: "aapl-20220924.htm", want: "aapl-20220924.htm"},
+		{name: "Spaces kept", value: "DEF 14A", want: "DEF 14A"},
+		{name: "Encoded form", value: "10-K%2FA", want: "10-K%2FA"},
+		{name: "Reserved characters replaced", value: `a<b>c:d"e|f?g*h.htm`, want: "a_b_c_d_e_f_g_h.htm"},
+		{name: "Trailing dots and spaces removed", value: "report. . ", want: "report"},
+		{name: "Reserved device name prefixed", value: "con.htm", want: "_con.htm"},
+		{name: "Empty", value: "", wantErr: true},
+		{name: "Current directory", value: ".", wantErr: true},
+		{name: "Parent directory", value: "..", wantErr: true},
+		{name: "Slash", value: "../etc/passwd", wantErr: true},
+		{name: "Backslash", value: `..\windows`, wantErr: true},
+		{name: "NUL byte", value: "a\x00b", wantErr: true},
+		{name: "Newline", value: "a\nb", wantErr: true},
+		{name: "Only dots and spaces", value: ". .", wantErr: true},
+		{name: "Too long", value: strings.Repeat("a", 256), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sanitizePathComponent("document name", tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("sanitizePathComponent(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("sanitizePathComponent(%q) error = %v, want ErrUnsafePath", tt.value, err)
+			}
+			if got != tt.want {
+				t.Errorf("sanitizePathComponent(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateDocumentName(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+	}{
+		{"aapl-20220924.htm", false},
+		{"xslF345X05/wk-form4.xml", false},
+		{"/etc/passwd", true},
+		{"../../other/doc.htm", true},
+		{"xslF345X05/../../doc.htm", true},
+		{"a//b.htm", true},
+		{`a\b.htm`, true},
+		{"doc.htm?x=1", true},
+		{"%2e%2e/doc.htm", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDocumentName(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDocumentName(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateAccessionNumber(t *testing.T) {
+	for accessionNumber, wantErr := range map[string]bool{
+		"0000320193-22-000108": false,
+		"000032019322000108":   false,
+		"0000320193-22-00010":  true,
+		"0000320193-22-00010/": true,
+		"../../../22-000108":   true,
+		"":                     true,
+	} {
+		if err := validateAccessionNumber(accessionNumber); (err != nil) != wantErr {
+			t.Errorf("validateAccessionNumber(%q) error = %v, wantErr %v", accessionNumber, err, wantErr)
+		}
+	}
+}
+
+func TestLayoutKeyRejectsUnsafeFields(t *testing.T) {
+	fields := LayoutFields{
+		CIK:             "0000320193",
+		Ticker:          "AAPL",
+		Form:            "10-K",
+		AccessionNumber: "0000320193-22-000108",
+		Filename:        "index.html",
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*LayoutFields)
+	}{
+		{"Ticker with separator", func(f *LayoutFields) { f.Ticker = "../AAPL" }},
+		{"Form with control character", func(f *LayoutFields) { f.Form = "10-K\n" }},
+		{"Form of dots", func(f *LayoutFields) { f.Form = ".." }},
+		{"Malformed accession number", func(f *LayoutFields) { f.AccessionNumber = "../0000320193" }},
+		{"Non-numeric CIK", func(f *LayoutFields) { f.CIK = "../x" }},
+		{"Filename with separator", func(f *LayoutFields) { f.Filename = "../index.html" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := fields
+			tt.modify(&f)
+			key, err := defaultLayout.Key(f)
+			if !errors.Is(err, ErrUnsafePath) {
+				t.Errorf("Key() = %q, %v, want ErrUnsafePath", key, err)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsRejectsUnsafeDocuments(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-23-000003", "0000320193-23-000002", "0000320193-23-000001"},
+			FilingDate:      []string{"2023-03-01", "2023-02-01", "2023-01-01"},
+			Form:            []string{"4", "4", "4"},
+			PrimaryDocument: []string{"../../../../../outside.htm", "xslF345X05/wk-form4.xml", `form:4.xml`},
+		}},
+	}
+
+	var mu sync.Mutex
+	var requested []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		mu.Lock()
+		requested = append(requested, r.URL.Path)
+		mu.Unlock()
+		w.Write([]byte("content"))
+	}))
+	defer server.Close()
+
+	root := t.TempDir()
+	folder := filepath.Join(root, "downloads")
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "4",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+	}
+
+	report, err := FetchAndSaveFilings(metadata, newTestClient(t, server))
+	if !errors.Is(err, ErrUnsafePath) {
+		t.Fatalf("FetchAndSaveFilings() error = %v, want ErrUnsafePath", err)
+	}
+
+	// The hostile entry is reported and never requested
+	rejected := report.Filings[0]
+	var unsafeErr *UnsafePathError
+	if !errors.As(rejected.Err, &unsafeErr) || unsafeErr.Kind != "document name" || len(rejected.Documents) != 0 {
+		t.Errorf("rejected filing = %+v, want an UnsafePathError for the document name", rejected)
+	}
+	for _, path := range requested {
+		if strings.Contains(path, "000032019323000003") {
+			t.Errorf("requested %s for the rejected filing", path)
+		}
+	}
+
+	// Nested EDGAR document names are saved under their base name
+	form4 := report.Filings[1]
+	if form4.Err != nil || form4.Documents[1].Name != "wk-form4.xml" {
+		t.Errorf("form 4 result = %+v, want wk-form4.xml saved", form4)
+	}
+
+	// Unusable names are sanitized
+	sanitized := report.Filings[2]
+	if sanitized.Err != nil || sanitized.Documents[1].Name != "form_4.xml" {
+		t.Errorf("sanitized result = %+v, want form_4.xml saved", sanitized)
+	}
+
+	// Nothing is written outside of the download folder
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "downloads" {
+		t.Errorf("root contains %v, want only the download folder", entries)
+	}
+}
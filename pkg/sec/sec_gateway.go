@@ -1,13 +1,17 @@
 package sec
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"golang.org/x/time/rate"
@@ -16,9 +20,99 @@ import (
 // SECClient represents a client for interacting with the SEC API.
 // It handles rate limiting, authentication, and communication with the SEC EDGAR database.
 type SECClient struct {
-	client    *http.Client
-	userAgent string
-	limiter   *rate.Limiter
+	client      *http.Client
+	timeout     time.Duration
+	userAgent   string
+	limiter     *rate.Limiter
+	retryPolicy RetryPolicy
+	endpoints   Endpoints
+}
+
+// ClientOption represents an option for NewSECClient.
+// It's a function that modifies an SECClient instance.
+// This follows the same functional options pattern as DownloadOption.
+type ClientOption func(*SECClient)
+
+// WithHTTPClient sets the HTTP client used to send requests.
+// Example: WithHTTPClient(&http.Client{Timeout: time.Minute})
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(s *SECClient) {
+		if httpClient != nil {
+			s.client = httpClient
+		}
+	}
+}
+
+// WithTransport sets the RoundTripper used to send requests.
+// The HTTP client is copied, so a client passed to WithHTTPClient is not modified.
+// Example: WithTransport(&http.Transport{MaxIdleConnsPerHost: 10})
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(s *SECClient) {
+		if transport != nil {
+			httpClient := *s.client
+			httpClient.Transport = transport
+			s.client = &httpClient
+		}
+	}
+}
+
+// WithTimeout sets how long a request may wait for the response headers, and then how
+// long each read of the body may wait for data. Time spent by the caller between reads
+// does not count, so a download fails only when the SEC stalls for that long: neither
+// large documents nor slow consumers are cut off. A timeout of 0 disables
+// the timeout. Any total timeout of the HTTP client is removed; the client is copied,
+// so a client passed to WithHTTPClient is not modified.
+// Example: WithTimeout(time.Minute)
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(s *SECClient) {
+		if timeout >= 0 {
+			httpClient := *s.client
+			httpClient.Timeout = 0
+			s.client = &httpClient
+			s.timeout = timeout
+		}
+	}
+}
+
+// WithRateLimit sets the maximum request rate and burst of the client's own rate limiter.
+// Values above the SEC's limit of 10 requests per second risk being throttled.
+// Example: WithRateLimit(5, 1)
+func WithRateLimit(requestsPerSecond float64, burst int) ClientOption {
+	return func(s *SECClient) {
+		if requestsPerSecond > 0 && burst > 0 {
+			s.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), burst)
+		}
+	}
+}
+
+// WithLimiter sets a rate limiter that may be shared with other clients,
+// so that several clients together stay within the SEC's request rate.
+// Example: WithLimiter(sharedLimiter)
+func WithLimiter(limiter *rate.Limiter) ClientOption {
+	return func(s *SECClient) {
+		if limiter != nil {
+			s.limiter = limiter
+		}
+	}
+}
+
+// WithRetryPolicy sets the policy used to retry transient failures.
+// Example: WithRetryPolicy(sec.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second})
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(s *SECClient) {
+		s.retryPolicy = policy
+	}
+}
+
+// WithEndpoints sets the endpoints the client sends its requests to.
+// Invalid endpoints are ignored and the defaults are kept.
+// Example: WithEndpoints(sec.Endpoints{WWWBaseURL: "http://localhost:8080", DataBaseURL: "http://localhost:8080"})
+func WithEndpoints(endpoints Endpoints) ClientOption {
+	return func(s *SECClient) {
+		if endpoints.Validate() == nil {
+			s.endpoints = endpoints
+		}
+	}
 }
 
 // NewSECClient creates a new SEC client with appropriate rate limiting.
@@ -26,63 +120,301 @@ type SECClient struct {
 // Parameters:
 //   - companyName: Your company name (required by SEC fair access policy)
 //   - emailAddress: Your email address (required by SEC fair access policy)
+//   - options: Variadic list of options to configure the client
 //
 // Returns:
 //   - A new SECClient instance configured for SEC API access
 //
-// Example: NewSECClient("YourCompany", "your@email.com")
-func NewSECClient(companyName, emailAddress string) *SECClient {
+// Example: NewSECClient("YourCompany", "your@email.com", WithTimeout(time.Minute))
+func NewSECClient(companyName, emailAddress string, options ...ClientOption) *SECClient {
 	userAgent := fmt.Sprintf("%s %s", companyName, emailAddress)
 
 	// 10 requests per second rate limit set by SEC
-	limiter := rate.NewLimiter(rate.Limit(SECRequestsPerSecMax), 1)
+	limiter := rate.NewLimiter(rate.Limit(SECRequestsPerSecMax), DefaultRateLimitBurst)
+
+	client := &SECClient{
+		client:      &http.Client{},
+		timeout:     DefaultRequestTimeout,
+		userAgent:   userAgent,
+		limiter:     limiter,
+		retryPolicy: DefaultRetryPolicy,
+		endpoints:   DefaultEndpoints(),
+	}
 
-	return &SECClient{
-		client:    &http.Client{Timeout: 30 * time.Second},
-		userAgent: userAgent,
-		limiter:   limiter,
+	// Apply options
+	for _, option := range options {
+		option(client)
 	}
+
+	return client
 }
 
-// callSEC makes a rate-limited call to the SEC API.
-// It's a helper method that uses the default background context.
-func (s *SECClient) callSEC(uri string, host string) (*http.Response, error) {
-	return s.callSECWithContext(context.Background(), uri, host)
+// SetRetryPolicy sets the policy used to retry transient failures such as
+// HTTP 429/5xx responses, connection resets and timeouts.
+//
+// Parameters:
+//   - policy: The retry policy to use for subsequent requests
+//
+// Example: client.SetRetryPolicy(sec.RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Second})
+func (s *SECClient) SetRetryPolicy(policy RetryPolicy) {
+	s.retryPolicy = policy
+}
+
+// SetEndpoints sets the endpoints the client sends its requests to.
+// This allows targeting an internal EDGAR mirror or a local stand-in server.
+//
+// Parameters:
+//   - endpoints: The endpoints to use for subsequent requests
+//
+// Returns:
+//   - nil on success, error if the endpoints are invalid
+//
+// Example: client.SetEndpoints(sec.Endpoints{WWWBaseURL: "https://edgar.internal", DataBaseURL: "https://edgar-data.internal"})
+func (s *SECClient) SetEndpoints(endpoints Endpoints) error {
+	if err := endpoints.Validate(); err != nil {
+		return err
+	}
+	s.endpoints = endpoints
+	return nil
+}
+
+// Endpoints returns the endpoints the client sends its requests to.
+func (s *SECClient) Endpoints() Endpoints {
+	return s.endpoints
 }
 
 // callSECWithContext makes a rate-limited call to the SEC API with a specific context.
-// It respects the SEC's rate limits and sets appropriate headers.
-func (s *SECClient) callSECWithContext(ctx context.Context, uri string, host string) (*http.Response, error) {
+// It respects the SEC's rate limits and sets appropriate headers. Transient failures
+// are retried according to the client's retry policy, and every attempt waits for the
+// rate limiter again.
+func (s *SECClient) callSECWithContext(ctx context.Context, uri string) (*http.Response, error) {
+	return s.callSECWithHeader(ctx, uri, nil)
+}
+
+// callSECWithHeader makes a rate-limited call to the SEC API like callSECWithContext,
+// adding the given request headers. Requests with a Range header also accept the
+// 206 Partial Content and 416 Range Not Satisfiable responses.
+func (s *SECClient) callSECWithHeader(ctx context.Context, uri string, header http.Header) (*http.Response, error) {
+	var resp *http.Response
+	err := s.retry(ctx, uri, func() error {
+		var err error
+		resp, err = s.doRequest(ctx, uri, header)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// retry calls attempt until it succeeds, fails permanently, or the client's retry policy
+// is exhausted, waiting between attempts as the policy says. An attempt covers a request
+// and the reading of its response, so that a connection lost while a body is read is
+// retried like one lost before the response arrived.
+func (s *SECClient) retry(ctx context.Context, uri string, attempt func() error) error {
+	policy := s.retryPolicy
+	for n := 1; ; n++ {
+		err := attempt()
+		if err == nil {
+			return nil
+		}
+
+		// Give up on permanent errors, cancellation and exhausted attempts
+		if n >= policy.attempts() || ctx.Err() != nil || !isRetryableError(err) {
+			return err
+		}
+
+		// Wait before the next attempt, honoring any Retry-After hint
+		var retryAfter time.Duration
+		var httpErr *HTTPError
+		if errors.As(err, &httpErr) {
+			retryAfter = httpErr.RetryAfter
+		}
+		if sleepErr := sleepContext(ctx, policy.backoff(n, retryAfter)); sleepErr != nil {
+			return fmt.Errorf("retry of %s aborted: %w", uri, sleepErr)
+		}
+	}
+}
+
+// getJSON requests a JSON document and decodes it into v. A response cut short is
+// requested again according to the client's retry policy.
+func (s *SECClient) getJSON(ctx context.Context, uri, resource string, v any) error {
+	return s.retry(ctx, uri, func() error {
+		resp, err := s.doRequest(ctx, uri, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		// Get the response body
+		body, err := getResponseBody(resp)
+		if err != nil {
+			return err
+		}
+		defer body.Close()
+
+		// Decode the JSON, keeping the beginning of the body for error reporting
+		var snippet snippetRecorder
+		if err := json.NewDecoder(io.TeeReader(body, &snippet)).Decode(v); err != nil {
+			return &DecodeError{Resource: resource, URL: uri, Snippet: snippet.String(), Err: err}
+		}
+		return nil
+	})
+}
+
+// doRequest performs a single rate-limited request to the SEC API.
+func (s *SECClient) doRequest(ctx context.Context, uri string, header http.Header) (*http.Response, error) {
 	// Wait for rate limiter
 	if err := s.limiter.Wait(ctx); err != nil {
-		return nil, fmt.Errorf("rate limiter error: %w", err)
+		return nil, limiterError(ctx, s.limiter, err)
 	}
 
+	// Cancel the request if the response or the next chunk of the body is overdue
+	ctx, cancel := context.WithCancel(ctx)
+	idle := newIdleTimer(s.timeout, cancel)
+
 	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
 	if err != nil {
+		idle.stop()
 		return nil, fmt.Errorf("failed to create request for %s: %w", uri, err)
 	}
 
 	// Set headers
 	req.Header.Set("User-Agent", s.userAgent)
 	req.Header.Set("Accept-Encoding", "gzip, deflate")
-	req.Header.Set("Host", host)
+	for name, values := range header {
+		req.Header[name] = values
+	}
 
 	// Send request
 	resp, err := s.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request for %s: %w", uri, err)
+		idle.stop()
+		return nil, fmt.Errorf("failed to send request for %s: %w", uri, idle.wrap(err))
 	}
+	idle.pause()
+	resp.Body = &idleTimeoutBody{ReadCloser: resp.Body, idle: idle}
 
 	// Check for HTTP errors
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("HTTP error %d for %s", resp.StatusCode, uri)
+	if resp.StatusCode != http.StatusOK && !isRangeResponse(header, resp.StatusCode) {
+		defer resp.Body.Close()
+		return nil, &HTTPError{
+			StatusCode: resp.StatusCode,
+			URL:        uri,
+			Snippet:    readSnippet(resp),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	return resp, nil
 }
 
+// errLimiterDeadline reports that the rate limiter would only allow a request after the
+// context's deadline. Retrying cannot help, unlike other timeouts.
+var errLimiterDeadline = errors.New("next request allowed after the context deadline")
+
+// limiterError wraps an error of the rate limiter's Wait. The limiter fails without
+// waiting if the next token would only be available after the context's deadline; that
+// error does not wrap context.DeadlineExceeded, so it is added here.
+func limiterError(ctx context.Context, limiter *rate.Limiter, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("rate limiter error: %w", ctx.Err())
+	}
+	// With a burst of at least one token, a deadline is the only reason left to fail
+	if _, ok := ctx.Deadline(); ok && limiter.Burst() >= 1 {
+		return fmt.Errorf("rate limiter error: %w: %w", errLimiterDeadline, context.DeadlineExceeded)
+	}
+	return fmt.Errorf("rate limiter error: %w", err)
+}
+
+// idleTimer cancels a request when its timeout elapses without progress. It only runs
+// while waiting for the network: it is paused once the response headers arrived and
+// between reads of the body, so that a consumer taking its time with the data it read
+// is not mistaken for a stalled connection. A zero timeout never expires.
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	cancel  context.CancelFunc
+	expired atomic.Bool
+}
+
+// newIdleTimer starts a timer that calls cancel once the timeout elapses.
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout, cancel: cancel}
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() {
+			t.expired.Store(true)
+			cancel()
+		})
+	}
+	return t
+}
+
+// resume restarts the timeout when the consumer waits for data again.
+func (t *idleTimer) resume() {
+	if t.timer != nil && !t.expired.Load() {
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// pause stops the timeout while the consumer is not waiting for data. A timer that has
+// already expired still fails the request.
+func (t *idleTimer) pause() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
+
+// stop stops the timer and releases the request's context.
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	t.cancel()
+}
+
+// wrap replaces the cancellation error of an expired request with a timeout error,
+// which is retried like other network timeouts.
+func (t *idleTimer) wrap(err error) error {
+	if err == nil || !t.expired.Load() {
+		return err
+	}
+	return fmt.Errorf("no data received within %s: %w", t.timeout, os.ErrDeadlineExceeded)
+}
+
+// idleTimeoutBody is a response body that runs the request's idle timer for the
+// duration of every read.
+type idleTimeoutBody struct {
+	io.ReadCloser
+	idle *idleTimer
+}
+
+// Read reads from the body, failing with a timeout error if the body stalled.
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	b.idle.resume()
+	n, err := b.ReadCloser.Read(p)
+	b.idle.pause()
+	if err != nil && !errors.Is(err, io.EOF) {
+		err = b.idle.wrap(err)
+	}
+	return n, err
+}
+
+// Close closes the body and stops the idle timer.
+func (b *idleTimeoutBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.idle.stop()
+	return err
+}
+
+// isRangeResponse reports whether a status code answers a Range request.
+func isRangeResponse(header http.Header, statusCode int) bool {
+	if header.Get("Range") == "" {
+		return false
+	}
+	return statusCode == http.StatusPartialContent || statusCode == http.StatusRequestedRangeNotSatisfiable
+}
+
 // getResponseBody handles decompression of gzipped responses.
 // It returns a ReadCloser that should be closed by the caller.
 func getResponseBody(resp *http.Response) (io.ReadCloser, error) {
@@ -108,22 +440,27 @@ func getResponseBody(resp *http.Response) (io.ReadCloser, error) {
 //   - The contents of the filing as a byte slice and nil error on success
 //   - nil and error on failure
 func (s *SECClient) DownloadFiling(uri string) ([]byte, error) {
-	// Make the request
-	resp, err := s.callSEC(uri, HostWWWSEC)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return s.DownloadFilingWithContext(context.Background(), uri)
+}
 
-	// Get the response body
-	body, err := getResponseBody(resp)
-	if err != nil {
+// DownloadFilingWithContext downloads a filing from the SEC EDGAR database into memory.
+// Use DownloadFilingToWithContext or DownloadFilingStreamWithContext for large documents.
+// The context bounds both the wait for the rate limiter and the HTTP request,
+// including reading the response body.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - uri: The URI of the filing to download
+//
+// Returns:
+//   - The contents of the filing as a byte slice and nil error on success
+//   - nil and error on failure
+func (s *SECClient) DownloadFilingWithContext(ctx context.Context, uri string) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := s.DownloadFilingToWithContext(ctx, &buf, uri); err != nil {
 		return nil, err
 	}
-	defer body.Close()
-
-	// Read the response body
-	return io.ReadAll(body)
+	return buf.Bytes(), nil
 }
 
 // GetListOfAvailableFilings retrieves the list of available filings for a CIK.
@@ -135,27 +472,59 @@ func (s *SECClient) DownloadFiling(uri string) ([]byte, error) {
 //   - A SubmissionData object containing filing metadata and nil error on success
 //   - nil and error on failure
 func (s *SECClient) GetListOfAvailableFilings(uri string) (*SubmissionData, error) {
-	// Make the request
-	resp, err := s.callSEC(uri, HostDataSEC)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return s.GetListOfAvailableFilingsWithContext(context.Background(), uri)
+}
 
-	// Get the response body
-	body, err := getResponseBody(resp)
-	if err != nil {
+// GetListOfAvailableFilingsWithContext retrieves the list of available filings for a CIK.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - uri: The URI to the submissions file
+//
+// Returns:
+//   - A SubmissionData object containing filing metadata and nil error on success
+//   - nil and error on failure
+func (s *SECClient) GetListOfAvailableFilingsWithContext(ctx context.Context, uri string) (*SubmissionData, error) {
+	// Request and decode the JSON
+	var submissionData SubmissionData
+	if err := s.getJSON(ctx, uri, "submission data", &submissionData); err != nil {
 		return nil, err
 	}
-	defer body.Close()
 
-	// Decode the JSON
-	var submissionData SubmissionData
-	if err := json.NewDecoder(body).Decode(&submissionData); err != nil {
-		return nil, fmt.Errorf("failed to decode submission data: %w", err)
+	return &submissionData, nil
+}
+
+// GetSubmissionsPage retrieves an additional page of a company's submission history.
+// The pages are listed in SubmissionData.Filings.Files and contain filings that are
+// too old to be included in the "filings.recent" object.
+//
+// Parameters:
+//   - uri: The URI to the submissions page file
+//
+// Returns:
+//   - A FilingColumns object containing filing metadata and nil error on success
+//   - nil and error on failure
+func (s *SECClient) GetSubmissionsPage(uri string) (*FilingColumns, error) {
+	return s.GetSubmissionsPageWithContext(context.Background(), uri)
+}
+
+// GetSubmissionsPageWithContext retrieves an additional page of a company's submission history.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - uri: The URI to the submissions page file
+//
+// Returns:
+//   - A FilingColumns object containing filing metadata and nil error on success
+//   - nil and error on failure
+func (s *SECClient) GetSubmissionsPageWithContext(ctx context.Context, uri string) (*FilingColumns, error) {
+	// Request and decode the JSON
+	var page FilingColumns
+	if err := s.getJSON(ctx, uri, "submissions page", &page); err != nil {
+		return nil, err
 	}
 
-	return &submissionData, nil
+	return &page, nil
 }
 
 // GetTickerMetadata retrieves the ticker to CIK mapping from the SEC.
@@ -164,31 +533,29 @@ func (s *SECClient) GetListOfAvailableFilings(uri string) (*SubmissionData, erro
 //   - A map of ticker symbols to CIK numbers and nil error on success
 //   - nil and error on failure
 func (s *SECClient) GetTickerMetadata() (map[string]string, error) {
+	return s.GetTickerMetadataWithContext(context.Background())
+}
+
+// GetTickerMetadataWithContext retrieves the ticker to CIK mapping from the SEC.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//
+// Returns:
+//   - A map of ticker symbols to CIK numbers and nil error on success
+//   - nil and error on failure
+func (s *SECClient) GetTickerMetadataWithContext(ctx context.Context) (map[string]string, error) {
 	// Fetch ticker metadata for all exchanges
-	return s.fetchTickerMetadata(URLCIKMapping)
+	return s.fetchTickerMetadata(ctx, s.endpoints.CIKMappingURL())
 }
 
 // fetchTickerMetadata fetches ticker metadata from a URL.
 // It's a helper method that handles the actual API call and JSON processing.
-func (s *SECClient) fetchTickerMetadata(url string) (map[string]string, error) {
-	// Make the request
-	resp, err := s.callSEC(url, HostWWWSEC)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	// Get the response body
-	body, err := getResponseBody(resp)
-	if err != nil {
-		return nil, err
-	}
-	defer body.Close()
-
-	// Decode the JSON
+func (s *SECClient) fetchTickerMetadata(ctx context.Context, url string) (map[string]string, error) {
+	// Request and decode the JSON
 	var tickerMetadata TickerMetadata
-	if err := json.NewDecoder(body).Decode(&tickerMetadata); err != nil {
-		return nil, fmt.Errorf("failed to decode ticker metadata: %w", err)
+	if err := s.getJSON(ctx, url, "ticker metadata", &tickerMetadata); err != nil {
+		return nil, err
 	}
 
 	// Create a map of ticker to CIK
@@ -3,11 +3,17 @@ package sec
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"reflect"
 	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 func TestNewSECClient(t *testing.T) {
@@ -33,6 +39,84 @@ func TestNewSECClient(t *testing.T) {
 	}
 }
 
+func TestNewSECClientOptions(t *testing.T) {
+	sharedLimiter := rate.NewLimiter(rate.Limit(2), 3)
+	customHTTPClient := &http.Client{Timeout: time.Minute}
+	customTransport := &http.Transport{}
+	customEndpoints := Endpoints{WWWBaseURL: "http://localhost:1", DataBaseURL: "http://localhost:2"}
+	customPolicy := RetryPolicy{MaxAttempts: 2}
+
+	t.Run("Defaults", func(t *testing.T) {
+		client := NewSECClient("TestCompany", "test@example.com")
+		if client.timeout != DefaultRequestTimeout || client.client.Timeout != 0 {
+			t.Errorf("timeout = %v, client Timeout = %v, want %v and no total timeout", client.timeout, client.client.Timeout, DefaultRequestTimeout)
+		}
+		if client.limiter.Limit() != rate.Limit(SECRequestsPerSecMax) || client.limiter.Burst() != DefaultRateLimitBurst {
+			t.Errorf("Limiter = %v/%d, want %v/%d", client.limiter.Limit(), client.limiter.Burst(), SECRequestsPerSecMax, DefaultRateLimitBurst)
+		}
+		if client.Endpoints() != DefaultEndpoints() {
+			t.Errorf("Endpoints() = %v, want defaults", client.Endpoints())
+		}
+	})
+
+	t.Run("Custom HTTP client with transport and timeout", func(t *testing.T) {
+		client := NewSECClient("TestCompany", "test@example.com",
+			WithHTTPClient(customHTTPClient),
+			WithTransport(customTransport),
+			WithTimeout(5*time.Second),
+		)
+		if client.client.Transport != customTransport {
+			t.Errorf("Transport was not set")
+		}
+		if client.timeout != 5*time.Second || client.client.Timeout != 0 {
+			t.Errorf("timeout = %v, client Timeout = %v, want %v and no total timeout", client.timeout, client.client.Timeout, 5*time.Second)
+		}
+		if customHTTPClient.Timeout != time.Minute || customHTTPClient.Transport != nil {
+			t.Errorf("WithHTTPClient() client was modified by later options")
+		}
+	})
+
+	t.Run("Rate limit", func(t *testing.T) {
+		client := NewSECClient("TestCompany", "test@example.com", WithRateLimit(5, 2))
+		if client.limiter.Limit() != rate.Limit(5) || client.limiter.Burst() != 2 {
+			t.Errorf("Limiter = %v/%d, want 5/2", client.limiter.Limit(), client.limiter.Burst())
+		}
+	})
+
+	t.Run("Shared limiter, retry policy and endpoints", func(t *testing.T) {
+		first := NewSECClient("TestCompany", "test@example.com", WithLimiter(sharedLimiter))
+		second := NewSECClient("TestCompany", "test@example.com",
+			WithLimiter(sharedLimiter),
+			WithRetryPolicy(customPolicy),
+			WithEndpoints(customEndpoints),
+		)
+		if first.limiter != sharedLimiter || second.limiter != sharedLimiter {
+			t.Errorf("Limiter is not shared")
+		}
+		if second.retryPolicy != customPolicy {
+			t.Errorf("retryPolicy = %v, want %v", second.retryPolicy, customPolicy)
+		}
+		if second.Endpoints() != customEndpoints {
+			t.Errorf("Endpoints() = %v, want %v", second.Endpoints(), customEndpoints)
+		}
+	})
+
+	t.Run("Invalid values keep defaults", func(t *testing.T) {
+		client := NewSECClient("TestCompany", "test@example.com",
+			WithHTTPClient(nil),
+			WithRateLimit(0, 0),
+			WithLimiter(nil),
+			WithEndpoints(Endpoints{WWWBaseURL: "not a url"}),
+		)
+		if client.client == nil || client.limiter == nil {
+			t.Fatalf("NewSECClient() left nil client or limiter")
+		}
+		if client.Endpoints() != DefaultEndpoints() {
+			t.Errorf("Endpoints() = %v, want defaults", client.Endpoints())
+		}
+	})
+}
+
 func TestGetResponseBody(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -185,22 +269,8 @@ func TestSECClientGetListOfAvailableFilings(t *testing.T) {
 			statusCode: http.StatusOK,
 			responseObj: &SubmissionData{
 				CIK: "0000320193",
-				Filings: struct {
-					Recent struct {
-						AccessionNumber []string `json:"accessionNumber"`
-						FilingDate      []string `json:"filingDate"`
-						Form            []string `json:"form"`
-						PrimaryDocument []string `json:"primaryDocument"`
-						Items           []string `json:"items"`
-					} `json:"recent"`
-				}{
-					Recent: struct {
-						AccessionNumber []string `json:"accessionNumber"`
-						FilingDate      []string `json:"filingDate"`
-						Form            []string `json:"form"`
-						PrimaryDocument []string `json:"primaryDocument"`
-						Items           []string `json:"items"`
-					}{
+				Filings: SubmissionFilings{
+					Recent: FilingColumns{
 						AccessionNumber: []string{"0000320193-22-000001"},
 						FilingDate:      []string{"2022-01-01"},
 						Form:            []string{"10-K"},
@@ -344,7 +414,7 @@ func TestSECClientGetTickerMetadata(t *testing.T) {
 			client := NewSECClient("TestCompany", "test@example.com")
 
 			// Test the fetchTickerMetadata directly since we can't override URLCIKMapping
-			cikMap, err := client.fetchTickerMetadata(server.URL)
+			cikMap, err := client.fetchTickerMetadata(context.Background(), server.URL)
 
 			// Check for expected error
 			if (err != nil) != tt.wantErr {
@@ -367,3 +437,168 @@ func TestSECClientGetTickerMetadata(t *testing.T) {
 		})
 	}
 }
+
+func TestSECClientGetSubmissionsPage(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		wantCount  int
+		wantErr    bool
+	}{
+		{
+			name:       "Successful response",
+			statusCode: http.StatusOK,
+			body:       `{"accessionNumber":["0000320193-05-000001","0000320193-04-000001"],"filingDate":["2005-01-01","2004-01-01"],"form":["10-K","10-K"],"primaryDocument":["a.htm","b.htm"]}`,
+			wantCount:  2,
+			wantErr:    false,
+		},
+		{
+			name:       "HTTP error",
+			statusCode: http.StatusNotFound,
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid JSON",
+			statusCode: http.StatusOK,
+			body:       "{invalid json}",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Create test server
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			// Create client
+			client := NewSECClient("TestCompany", "test@example.com")
+
+			// Call function under test
+			page, err := client.GetSubmissionsPage(server.URL)
+
+			// Check for expected error
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetSubmissionsPage() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			if err == nil && len(page.AccessionNumber) != tt.wantCount {
+				t.Errorf("GetSubmissionsPage() AccessionNumber count = %v, want %v", len(page.AccessionNumber), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestSECClientContextCancellation(t *testing.T) {
+	// The server never answers before the client gives up
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewSECClient("TestCompany", "test@example.com")
+
+	t.Run("Cancelled before the request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := client.DownloadFilingWithContext(ctx, server.URL)
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("DownloadFilingWithContext() error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("Deadline during the request", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		_, err := client.GetListOfAvailableFilingsWithContext(ctx, server.URL)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("GetListOfAvailableFilingsWithContext() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+
+	t.Run("Deadline before the rate limiter allows the request", func(t *testing.T) {
+		// The only token is used, and the next one comes long after the deadline
+		limiter := rate.NewLimiter(rate.Every(time.Hour), 1)
+		limiter.Allow()
+		client := NewSECClient("TestCompany", "test@example.com", WithLimiter(limiter))
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		_, err := client.DownloadFilingWithContext(ctx, server.URL)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("DownloadFilingWithContext() error = %v, want context.DeadlineExceeded", err)
+		}
+	})
+}
+
+func TestFilingColumnsFiling(t *testing.T) {
+	body := `{
+		"accessionNumber": ["0000320193-23-000106", "0000320193-23-000077"],
+		"filingDate": ["2023-11-03", "2023-08-04"],
+		"reportDate": ["2023-09-30", "2023-07-01"],
+		"acceptanceDateTime": ["2023-11-02T18:08:27.000Z", "2023-08-03T18:04:43.000Z"],
+		"act": ["34", "34"],
+		"form": ["10-K", "10-Q"],
+		"fileNumber": ["001-36743", "001-36743"],
+		"filmNumber": ["231373899", "231140438"],
+		"items": ["", ""],
+		"core_type": ["10-K", "10-Q"],
+		"size": [9781643, 6342542],
+		"isXBRL": [1, 0],
+		"isInlineXBRL": [1, 0],
+		"primaryDocument": ["aapl-20230930.htm", "aapl-20230701.htm"],
+		"primaryDocDescription": ["10-K", "10-Q"]
+	}`
+
+	var columns FilingColumns
+	if err := json.Unmarshal([]byte(body), &columns); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	if columns.Len() != 2 {
+		t.Fatalf("Len() = %d, want 2", columns.Len())
+	}
+
+	want := FilingInfo{
+		AccessionNumber:       "0000320193-23-000106",
+		FilingDate:            "2023-11-03",
+		Form:                  "10-K",
+		PrimaryDocument:       "aapl-20230930.htm",
+		ReportDate:            "2023-09-30",
+		AcceptanceDateTime:    "2023-11-02T18:08:27.000Z",
+		Act:                   "34",
+		FileNumber:            "001-36743",
+		FilmNumber:            "231373899",
+		Size:                  9781643,
+		IsXBRL:                true,
+		IsInlineXBRL:          true,
+		CoreType:              "10-K",
+		PrimaryDocDescription: "10-K",
+	}
+	if got := columns.Filing(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("Filing(0) = %+v, want %+v", got, want)
+	}
+
+	if got := columns.Filing(1); got.IsXBRL || got.IsInlineXBRL || got.Size != 6342542 {
+		t.Errorf("Filing(1) = %+v, want no XBRL and size 6342542", got)
+	}
+
+	// Missing columns leave fields empty
+	partial := FilingColumns{AccessionNumber: []string{"0000320193-23-000106"}}
+	if got := partial.Filing(0); !reflect.DeepEqual(got, FilingInfo{AccessionNumber: "0000320193-23-000106"}) {
+		t.Errorf("Filing(0) with missing columns = %+v", got)
+	}
+}
@@ -0,0 +1,521 @@
+package sec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// filingStage collects the documents of a filing in a staging directory, so that the
+// filing only appears on disk once every document has been downloaded. The staging
+// directory is created in the spool directory of the storage, next to a lock file that
+// keeps it from being swept while in use, and is swept along with stale spools if a
+// crash leaves it behind.
+type filingStage struct {
+	// dir is the filing's directory
+	dir string
+	// stagingDir is the temporary directory the documents are written to
+	stagingDir string
+	// lock is held until the stage is discarded
+	lock *fileLock
+}
+
+// stageDirExt is the extension of a staging directory in the spool directory
+const stageDirExt = ".stage"
+
+// newFilingStage creates the staging directory for a filing saved in dir, in the spool
+// directory root, which must be on the same device as dir.
+func newFilingStage(root, dir string) (*filingStage, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create directories: %w", err)
+	}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	// Create the lock before the directory, so that the directory is never swept
+	lockFile, err := os.CreateTemp(root, filepath.Base(dir)+".*"+stageDirExt+spoolLockFilename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging lock: %w", err)
+	}
+	_ = lockFile.Close()
+	lock := newFileLock(lockFile.Name(), spoolLockStaleAge)
+
+	stagingDir := strings.TrimSuffix(lockFile.Name(), spoolLockFilename)
+	if err := os.Mkdir(stagingDir, 0755); err != nil {
+		lock.unlock()
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+
+	return &filingStage{dir: dir, stagingDir: stagingDir, lock: lock}, nil
+}
+
+// stagedPath returns the location in the staging directory of a document saved at path.
+func (s *filingStage) stagedPath(path string) (string, error) {
+	rel, err := filepath.Rel(s.dir, path)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("document %s is outside of the filing directory %s", path, s.dir)
+	}
+	return filepath.Join(s.stagingDir, rel), nil
+}
+
+// commit moves the staged documents into the filing's directory. If the directory does
+// not exist yet, the staging directory is renamed into place in a single step; otherwise
+// each document is renamed over its destination.
+func (s *filingStage) commit(paths []string) error {
+	if _, err := os.Lstat(s.dir); os.IsNotExist(err) {
+		if err := os.Rename(s.stagingDir, s.dir); err != nil {
+			return fmt.Errorf("failed to commit filing directory: %w", err)
+		}
+		syncDir(filepath.Dir(s.dir))
+		return nil
+	}
+
+	for _, path := range paths {
+		staged, err := s.stagedPath(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create directories: %w", err)
+		}
+		if err := os.Rename(staged, path); err != nil {
+			return fmt.Errorf("failed to commit document: %w", err)
+		}
+	}
+	syncDir(s.dir)
+	return nil
+}
+
+// discard removes the staging directory and any document left in it, and releases its
+// lock.
+func (s *filingStage) discard() {
+	_ = os.RemoveAll(s.stagingDir)
+	s.lock.unlock()
+}
+
+// syncDir flushes a directory so that renames into it survive a crash.
+// Errors are ignored because not every platform supports syncing directories.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		_ = d.Sync()
+		_ = d.Close()
+	}
+}
+
+// filingSpool holds the documents of a filing in files while they are downloaded, so
+// that they can be stored together once all of them have been fetched without keeping
+// their contents in memory. The spool directory of a filing has a fixed name and is kept
+// after a failed or interrupted download that may succeed later, so that the next
+// attempt resumes its documents instead of downloading them again. A lock file in the
+// directory keeps other downloads, in this or another process, from using it meanwhile.
+type filingSpool struct {
+	dir string
+	// resumable is false for a temporary spool directory, which is always removed
+	resumable bool
+	// lock is held until the spool is released
+	lock *fileLock
+	// files maps the storage key of every downloaded document to its spool file
+	files map[string]string
+}
+
+// spoolRecord is saved next to a spooled document, to resume its download.
+type spoolRecord struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+const (
+	// spoolLockFilename is the name of the lock file of a spool directory in use
+	spoolLockFilename = ".lock"
+	// spoolLockStaleAge is the age after which a lock that was not refreshed is considered
+	// left behind by a crashed process
+	spoolLockStaleAge = time.Hour
+	// spoolRecordExt is the extension of the record of a spooled document
+	spoolRecordExt = ".json"
+	// spoolDirName is the directory that documents are spooled in: in the root of a file
+	// system storage, or in the system's temporary directory for other storages
+	spoolDirName = ".sec-downloader-spool"
+	// spoolDirExt is the extension of the spool directory of a filing
+	spoolDirExt = ".partial"
+	// manifestLockStaleAge is the age after which a manifest lock that was not refreshed
+	// is considered left behind by a crashed process
+	manifestLockStaleAge = time.Minute
+	// lockPollInterval is the time between two attempts to take a lock held by another
+	// process
+	lockPollInterval = 10 * time.Millisecond
+)
+
+// newFilingSpool opens the spool directory of a filing in the spool directory root. If
+// another download of the filing holds its lock, a new temporary directory is used
+// instead and nothing is resumed.
+func newFilingSpool(root, accessionNumber string) (*filingSpool, error) {
+	dir := filepath.Join(root, accessionNumber+spoolDirExt)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+
+	lock, err := lockSpool(dir)
+	if err != nil {
+		return nil, err
+	}
+	if lock != nil {
+		// Mark the directory as used, so that it is not swept as stale
+		now := time.Now()
+		_ = os.Chtimes(dir, now, now)
+		return &filingSpool{dir: dir, resumable: true, lock: lock, files: make(map[string]string)}, nil
+	}
+
+	// The temporary directory is locked too, so that it is not swept while in use. A sweep
+	// may remove it before it is locked, in which case another one is created.
+	for range 3 {
+		dir, err := os.MkdirTemp(root, accessionNumber+".*"+spoolDirExt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create spool directory: %w", err)
+		}
+		lock, err := lockSpool(dir)
+		if err != nil {
+			_ = os.RemoveAll(dir)
+			return nil, err
+		}
+		if lock != nil {
+			return &filingSpool{dir: dir, lock: lock, files: make(map[string]string)}, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to lock spool directory for filing %s", accessionNumber)
+}
+
+// lockSpool creates the lock file of a spool directory and returns it, or nil if another
+// download holds it or the directory was removed meanwhile. A lock that was not refreshed
+// for spoolLockStaleAge is taken over.
+func lockSpool(dir string) (*fileLock, error) {
+	return tryLockFile(filepath.Join(dir, spoolLockFilename), spoolLockStaleAge)
+}
+
+// fileLock is a lock file held by this process. The modification time of the file is
+// refreshed while the lock is held, so that other processes do not take it over as left
+// behind by a crashed one.
+type fileLock struct {
+	path string
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// tryLockFile creates the lock file at path and returns the lock, or nil if another
+// process holds it or the directory of the file does not exist. A lock that was not
+// refreshed for staleAge is taken over.
+func tryLockFile(path string, staleAge time.Duration) (*fileLock, error) {
+	for range 2 {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = fmt.Fprintf(file, "%d\n", os.Getpid())
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				_ = os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", err)
+			}
+			return newFileLock(path, staleAge), nil
+		}
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		// Take over a lock left behind by a crashed process
+		if !removeStaleLock(path, staleAge) {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
+
+// waitLockFile waits until it holds the lock file at path, trying again every
+// lockPollInterval while another process holds it. A lock that was not refreshed for
+// staleAge is taken over.
+func waitLockFile(path string, staleAge time.Duration) (*fileLock, error) {
+	for {
+		lock, err := tryLockFile(path, staleAge)
+		if err != nil || lock != nil {
+			return lock, err
+		}
+		if _, err := os.Stat(filepath.Dir(path)); err != nil {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// removeStaleLock removes the lock file at path if it was not refreshed for staleAge,
+// and reports whether the lock may be created again. The file is first renamed to a
+// unique name, so that of several processes finding the same stale lock only one
+// removes it: a process that finds it renamed another file than the one it examined,
+// because the lock was taken over meanwhile, puts that file back.
+func removeStaleLock(path string, staleAge time.Duration) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if time.Since(info.ModTime()) < staleAge {
+		return false
+	}
+
+	stalePath := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%d-%d.tmp", filepath.Base(path), os.Getpid(), time.Now().UnixNano()))
+	if err := os.Rename(path, stalePath); err != nil {
+		return errors.Is(err, os.ErrNotExist)
+	}
+	if renamed, err := os.Stat(stalePath); err != nil || !os.SameFile(info, renamed) {
+		// Put the lock of the process that took it over back, unless the lock was
+		// created again meanwhile
+		if err := os.Link(stalePath, path); err != nil && !errors.Is(err, os.ErrExist) {
+			_ = os.Rename(stalePath, path)
+		}
+		_ = os.Remove(stalePath)
+		return false
+	}
+	_ = os.Remove(stalePath)
+	return true
+}
+
+// newFileLock starts refreshing the lock file at path, often enough that it does not
+// become stale after staleAge.
+func newFileLock(path string, staleAge time.Duration) *fileLock {
+	l := &fileLock{path: path, stop: make(chan struct{}), done: make(chan struct{})}
+	go func() {
+		defer close(l.done)
+		ticker := time.NewTicker(staleAge / 4)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				now := time.Now()
+				_ = os.Chtimes(l.path, now, now)
+			}
+		}
+	}()
+	return l
+}
+
+// unlock stops refreshing the lock and removes its file. It may be called more than once.
+func (l *fileLock) unlock() {
+	l.once.Do(func() {
+		close(l.stop)
+		<-l.done
+		_ = os.Remove(l.path)
+	})
+}
+
+// download downloads a document into its spool file, resuming the part of it left by an
+// earlier attempt, and returns the description of the document.
+func (s *filingSpool) download(ctx context.Context, client *SECClient, key, uri string) (*DocumentInfo, error) {
+	// Both files are named after the key, so that the next attempt finds them
+	sum := sha256.Sum256([]byte(key))
+	name := hex.EncodeToString(sum[:8])
+	docPath := filepath.Join(s.dir, name+"-"+path.Base(key))
+	recordPath := filepath.Join(s.dir, name+spoolRecordExt)
+
+	file, err := os.OpenFile(docPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open spool file: %w", err)
+	}
+	defer file.Close()
+
+	// Only resume a download of the same URL
+	var partial DocumentInfo
+	if record, err := readSpoolRecord(recordPath); err == nil && record.URL == uri {
+		partial = DocumentInfo{URL: uri, ETag: record.ETag, LastModified: record.LastModified}
+	}
+
+	info, err := client.resumeDownload(ctx, file, uri, partial, func(info DocumentInfo) error {
+		return writeSpoolRecord(recordPath, spoolRecord{URL: uri, ETag: info.ETag, LastModified: info.LastModified})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.files[key] = docPath
+	return info, nil
+}
+
+// readSpoolRecord reads the record of a spooled document.
+func readSpoolRecord(recordPath string) (*spoolRecord, error) {
+	data, err := os.ReadFile(recordPath)
+	if err != nil {
+		return nil, err
+	}
+	var record spoolRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// writeSpoolRecord saves the record of a spooled document.
+func writeSpoolRecord(recordPath string, record spoolRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(recordPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write spool record: %w", err)
+	}
+	return nil
+}
+
+// store stores the downloaded documents of the given objects, whose bodies are read from
+// their spool files.
+func (s *filingSpool) store(ctx context.Context, storage Storage, objects []StorageObject) error {
+	objects = slices.Clone(objects)
+	for i := range objects {
+		file, err := os.Open(s.files[objects[i].Key])
+		if err != nil {
+			return fmt.Errorf("failed to open spooled document %s: %w", objects[i].Key, err)
+		}
+		defer file.Close()
+		objects[i].Body = file
+	}
+	return putObjects(ctx, storage, objects)
+}
+
+// discard removes the spool directory and every document in it, and releases its lock.
+func (s *filingSpool) discard() {
+	_ = os.RemoveAll(s.dir)
+	s.lock.unlock()
+}
+
+// release ends the use of the spool after a download that failed with err, or after the
+// filing was stored and the spool discarded. A temporary spool directory is removed. A
+// resumable one is kept for the next attempt only if the error may not recur, such as
+// a cancellation or a network failure, and only with the documents that can be resumed:
+// those with at least one byte and an ETag or Last-Modified validator.
+func (s *filingSpool) release(err error) {
+	if !s.resumable || (err != nil && !isResumableError(err)) {
+		s.discard()
+		return
+	}
+	if !s.prune() {
+		s.discard()
+		return
+	}
+	s.lock.unlock()
+}
+
+// prune removes the spooled documents that cannot be resumed, and reports whether any
+// document is left.
+func (s *filingSpool) prune() bool {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return false
+	}
+
+	kept := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if name == spoolLockFilename || filepath.Ext(name) == spoolRecordExt || isTemporaryName(name) {
+			continue
+		}
+
+		// Documents are named after the record they share a prefix with
+		prefix, _, _ := strings.Cut(name, "-")
+		recordPath := filepath.Join(s.dir, prefix+spoolRecordExt)
+		info, err := entry.Info()
+		record, recordErr := readSpoolRecord(recordPath)
+		if err == nil && recordErr == nil && info.Size() > 0 && (record.ETag != "" || record.LastModified != "") {
+			kept = true
+			continue
+		}
+		_ = os.Remove(filepath.Join(s.dir, name))
+		_ = os.Remove(recordPath)
+	}
+	return kept
+}
+
+// isResumableError reports whether a download that failed with err may succeed on a
+// later attempt, so that its spooled documents are worth keeping.
+func isResumableError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) || isRetryableError(err)
+}
+
+// removeStaleSpools removes the spool directories in the spool directory root that have
+// not been used for maxAge and are not locked by a download, and returns how many were
+// removed. The staging directories left behind by crashed stores are removed as well,
+// whatever their age, once their lock is stale. Nothing else in root is removed.
+func removeStaleSpools(root string, maxAge time.Duration) (int, error) {
+	entries, err := os.ReadDir(root)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to list spool directories: %w", err)
+	}
+
+	removed := 0
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		dir := filepath.Join(root, name)
+		var lock *fileLock
+		switch {
+		case entry.IsDir() && strings.HasSuffix(name, spoolDirExt):
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < maxAge {
+				continue
+			}
+			// Lock the directory, so that no download starts using it while it is removed
+			lock, err = lockSpool(dir)
+			if err != nil || lock == nil {
+				continue
+			}
+		case entry.IsDir() && strings.HasSuffix(name, stageDirExt):
+			lock, err = tryLockFile(dir+spoolLockFilename, spoolLockStaleAge)
+			if err != nil || lock == nil {
+				continue
+			}
+		case strings.HasSuffix(name, stageDirExt+spoolLockFilename):
+			// Remove the lock of a staging directory that was removed by a crashed store
+			stagingDir := strings.TrimSuffix(dir, spoolLockFilename)
+			if _, err := os.Lstat(stagingDir); errors.Is(err, os.ErrNotExist) {
+				removeStaleLock(dir, spoolLockStaleAge)
+			}
+			continue
+		default:
+			continue
+		}
+
+		err = os.RemoveAll(dir)
+		lock.unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove spool directory %s: %w", name, err))
+			continue
+		}
+		removed++
+	}
+	return removed, errors.Join(errs...)
+}
+
+// spoolRoot returns the directory the documents of a filing are spooled in: a hidden
+// directory in the root of a file system storage, so that documents stay on the same
+// device, or a directory in the system's temporary directory.
+func spoolRoot(storage Storage) string {
+	if fsStorage, ok := storage.(*FileSystemStorage); ok {
+		return filepath.Join(fsStorage.root, spoolDirName)
+	}
+	return filepath.Join(os.TempDir(), spoolDirName)
+}
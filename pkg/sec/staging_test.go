@@ -0,0 +1,187 @@
+package sec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveDocumentAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "document.html")
+
+	// Overwriting replaces the whole file and leaves no temporary file behind
+	for _, content := range []string{"a much longer first version", "short"} {
+		if err := SaveDocument([]byte(content), path); err != nil {
+			t.Fatalf("SaveDocument() error = %v", err)
+		}
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("os.ReadFile() error = %v", err)
+		}
+		if string(got) != content {
+			t.Errorf("SaveDocument() saved %q, want %q", got, content)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("os.ReadDir() error = %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("SaveDocument() left %d files in the directory, want 1", len(entries))
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Errorf("SaveDocument() file mode = %v, %v, want 0644", info.Mode().Perm(), err)
+	}
+
+	// Failures leave the destination untouched
+	if err := SaveDocument([]byte("content"), filepath.Join(path, "child.html")); err == nil {
+		t.Errorf("SaveDocument() under a file succeeded, want error")
+	}
+}
+
+func TestFetchAndSaveFilingsStaging(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm"},
+		}},
+	}
+
+	folder := t.TempDir()
+	formDir := filepath.Join(folder, RootSaveFolderName, "AAPL", "10-K")
+	filingDir := filepath.Join(formDir, "0000320193-22-000001")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var filingDirSeen bool
+	var cancelPrimary bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/submissions/CIK0000320193.json":
+			json.NewEncoder(w).Encode(submissions)
+		case strings.HasSuffix(r.URL.Path, "aapl-20220924.htm"):
+			// The index page is staged, not yet visible in the filing directory
+			if _, err := os.Stat(filingDir); err == nil {
+				filingDirSeen = true
+			}
+			if cancelPrimary {
+				cancel()
+				<-r.Context().Done()
+				return
+			}
+			w.Write([]byte("primary"))
+		default:
+			w.Write([]byte("index"))
+		}
+	}))
+	defer server.Close()
+
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+	}
+	client := newTestClient(t, server)
+
+	t.Run("Cancelled filing leaves nothing on disk", func(t *testing.T) {
+		cancelPrimary = true
+		defer func() { cancelPrimary = false }()
+
+		if _, err := FetchAndSaveFilingsWithContext(ctx, metadata, client); err == nil {
+			t.Fatalf("FetchAndSaveFilingsWithContext() error = nil, want cancellation")
+		}
+		entries, err := os.ReadDir(formDir)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatalf("os.ReadDir() error = %v", err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("cancelled download left %v in %s", entries, formDir)
+		}
+	})
+
+	t.Run("Filing appears once complete", func(t *testing.T) {
+		report, err := FetchAndSaveFilings(metadata, client)
+		if err != nil {
+			t.Fatalf("FetchAndSaveFilings() error = %v", err)
+		}
+		if filingDirSeen {
+			t.Errorf("filing directory was visible before all documents were downloaded")
+		}
+
+		for _, doc := range report.Filings[0].Documents {
+			if info, err := os.Stat(doc.Path); filepath.Dir(doc.Path) != filingDir || err != nil || info.Size() == 0 {
+				t.Errorf("DocumentResult %s Path = %q, want a saved file in %s", doc.Kind, doc.Path, filingDir)
+			}
+		}
+		entries, err := os.ReadDir(formDir)
+		if err != nil {
+			t.Fatalf("os.ReadDir() error = %v", err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "0000320193-22-000001" {
+			t.Errorf("download left %v in %s, want only the filing directory", entries, formDir)
+		}
+	})
+
+	t.Run("Existing filing is updated in place", func(t *testing.T) {
+		if _, err := FetchAndSaveFilings(metadata, client); err != nil {
+			t.Fatalf("FetchAndSaveFilings() error = %v", err)
+		}
+		entries, err := os.ReadDir(filingDir)
+		if err != nil {
+			t.Fatalf("os.ReadDir() error = %v", err)
+		}
+		if len(entries) != 2 {
+			t.Errorf("filing directory contains %d entries, want 2", len(entries))
+		}
+	})
+}
+
+func TestFilingStageSweep(t *testing.T) {
+	root := filepath.Join(t.TempDir(), spoolDirName)
+	dir := filepath.Join(t.TempDir(), "0000320193-22-000001")
+
+	// A stage in use is not swept
+	stage, err := newFilingStage(root, dir)
+	if err != nil {
+		t.Fatalf("newFilingStage() error = %v", err)
+	}
+	if removed, err := removeStaleSpools(root, 0); err != nil || removed != 0 {
+		t.Errorf("removeStaleSpools() = %d, %v, want 0", removed, err)
+	}
+	if _, err := os.Stat(stage.stagingDir); err != nil {
+		t.Fatalf("staging directory in use was removed: %v", err)
+	}
+
+	// A stage left behind by a crash is swept once its lock is stale
+	stage.lock.unlock()
+	old := time.Now().Add(-2 * spoolLockStaleAge)
+	lockPath := stage.stagingDir + spoolLockFilename
+	if err := os.WriteFile(lockPath, nil, 0644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	if err := os.Chtimes(lockPath, old, old); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+	if removed, err := removeStaleSpools(root, DefaultSpoolMaxAge); err != nil || removed != 1 {
+		t.Errorf("removeStaleSpools() = %d, %v, want 1", removed, err)
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("spool directory holds %v after the sweep, want nothing", entries)
+	}
+}
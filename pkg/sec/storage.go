@@ -0,0 +1,133 @@
+package sec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Storage is a backend that saved filings are written to.
+// Objects are addressed by slash-separated keys relative to the storage root
+// (e.g., "sec-edgar-filings/AAPL/10-K/0000320193-22-000001/index.html") and carry
+// string metadata such as the source URL and checksum of the document.
+// Write-only storages, such as archives being written, cannot read objects back and
+// return errors.ErrUnsupported from Get; Exists and List still describe what was stored.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// Put stores an object, replacing any object with the same key
+	Put(ctx context.Context, key string, body io.Reader, metadata map[string]string) error
+	// Get returns the contents and description of an object.
+	// The error matches ErrNotFound if the object does not exist, and
+	// errors.ErrUnsupported if the storage is write-only (e.g., ArchiveStorage).
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	// Exists reports whether an object exists
+	Exists(ctx context.Context, key string) (bool, error)
+	// List describes the objects whose keys start with prefix, sorted by key
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// BatchStorage is implemented by storages that can store the documents of a filing
+// together, so that a failure part-way does not leave a partial filing behind. The
+// orchestrator uses it to commit the documents of a filing; other storages receive one
+// Put per document. How far a batch is atomic depends on the storage: the documents of
+// FileSystemStorage and MemoryStorage appear together, S3Storage deletes the objects
+// of a failed batch again, and ArchiveStorage, which cannot remove what it wrote, marks
+// itself as failed.
+type BatchStorage interface {
+	Storage
+	// PutBatch stores all objects, replacing any objects with the same keys
+	PutBatch(ctx context.Context, objects []StorageObject) error
+}
+
+// StorageObject is an object to store with BatchStorage.PutBatch.
+type StorageObject struct {
+	// Key is the key of the object
+	Key string
+	// Body is the content of the object
+	Body io.Reader
+	// Metadata is the metadata of the object
+	Metadata map[string]string
+}
+
+// ObjectInfo describes a stored object.
+type ObjectInfo struct {
+	// Key is the key of the object
+	Key string
+	// Size is the size of the object in bytes
+	Size int64
+	// ModTime is the time when the object was last stored
+	ModTime time.Time
+	// Metadata is the metadata stored with the object. Storages that cannot keep
+	// metadata, and listings of S3 buckets, leave it empty.
+	Metadata map[string]string
+}
+
+// Metadata keys set by the orchestrator on saved documents.
+const (
+	// MetadataSourceURL is the URL the document was downloaded from
+	MetadataSourceURL = "source-url"
+	// MetadataAccessionNumber is the accession number of the filing
+	MetadataAccessionNumber = "accession-number"
+	// MetadataCIK is the CIK of the company
+	MetadataCIK = "cik"
+	// MetadataForm is the form type of the filing
+	MetadataForm = "form"
+	// MetadataFilingDate is the filing date of the filing
+	MetadataFilingDate = "filing-date"
+	// MetadataSHA256 is the hex-encoded SHA-256 checksum of the document
+	MetadataSHA256 = "sha256"
+	// MetadataETag is the ETag header returned by the SEC
+	MetadataETag = "etag"
+	// MetadataLastModified is the Last-Modified header returned by the SEC
+	MetadataLastModified = "last-modified"
+)
+
+// ValidateStorageKey checks that a key is a clean, relative, slash-separated path
+// that does not escape the storage root.
+//
+// Parameters:
+//   - key: The key to validate
+//
+// Returns:
+//   - nil if the key is valid, error otherwise
+func ValidateStorageKey(key string) error {
+	switch {
+	case key == "":
+		return fmt.Errorf("invalid storage key: empty")
+	case strings.HasPrefix(key, "/"):
+		return fmt.Errorf("invalid storage key %q: must be relative", key)
+	case strings.ContainsAny(key, "\\\x00"):
+		return fmt.Errorf("invalid storage key %q: contains a backslash or NUL byte", key)
+	case path.Clean(key) != key || key == "." || key == ".." || strings.HasPrefix(key, "../"):
+		return fmt.Errorf("invalid storage key %q: must be a clean path within the storage", key)
+	}
+	return nil
+}
+
+// putObjects stores objects with PutBatch if the storage supports it, or one at a time otherwise.
+func putObjects(ctx context.Context, storage Storage, objects []StorageObject) error {
+	if batch, ok := storage.(BatchStorage); ok {
+		return batch.PutBatch(ctx, objects)
+	}
+	for _, object := range objects {
+		if err := storage.Put(ctx, object.Key, object.Body, object.Metadata); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyMetadata returns a copy of a metadata map, or nil if it is empty.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+	return copied
+}
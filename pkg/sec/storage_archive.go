@@ -0,0 +1,257 @@
+package sec
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// paxMetadataPrefix prefixes the PAX records that hold object metadata in tar archives.
+const paxMetadataPrefix = "SECDOWNLOADER."
+
+// ArchiveStorage writes objects as entries of a tar or zip archive, e.g. to ship a
+// download as a single file. The archive is append-only: Exists and List describe the
+// entries written so far, but Get is not supported. Storing a key twice appends a
+// second entry, which replaces the first one when the archive is extracted.
+// Close must be called to finish the archive.
+//
+// An archive cannot remove what it has written: if an object fails part-way through, or
+// a batch fails after some of its objects were written, the archive holds a truncated
+// entry or a partial filing. The archive is then marked as failed, and every later call
+// to Put, PutBatch or Close returns the error; the archive should be discarded.
+type ArchiveStorage struct {
+	mu      sync.Mutex
+	tar     *tar.Writer
+	zip     *zip.Writer
+	entries map[string]ObjectInfo
+	closed  bool
+	// err is the failure that left a partial entry or filing in the archive
+	err error
+}
+
+// NewTarStorage creates a storage that writes objects to a tar archive.
+// Object metadata is kept in PAX records of the entries.
+//
+// Parameters:
+//   - w: The writer the archive is written to (e.g., an *os.File or a gzip.Writer)
+//
+// Returns:
+//   - A new ArchiveStorage instance
+//
+// Example: NewTarStorage(file)
+func NewTarStorage(w io.Writer) *ArchiveStorage {
+	return &ArchiveStorage{tar: tar.NewWriter(w), entries: make(map[string]ObjectInfo)}
+}
+
+// NewZipStorage creates a storage that writes objects to a zip archive.
+// Object metadata is kept as JSON in the comment of the entries.
+//
+// Parameters:
+//   - w: The writer the archive is written to (e.g., an *os.File)
+//
+// Returns:
+//   - A new ArchiveStorage instance
+//
+// Example: NewZipStorage(file)
+func NewZipStorage(w io.Writer) *ArchiveStorage {
+	return &ArchiveStorage{zip: zip.NewWriter(w), entries: make(map[string]ObjectInfo)}
+}
+
+// Put appends an object to the archive.
+func (s *ArchiveStorage) Put(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	return s.PutBatch(ctx, []StorageObject{{Key: key, Body: body, Metadata: metadata}})
+}
+
+// PutBatch appends several objects to the archive, in order. If an object fails after
+// anything of the batch was written, the archive is marked as failed.
+func (s *ArchiveStorage) PutBatch(ctx context.Context, objects []StorageObject) error {
+	for _, object := range objects {
+		if err := ValidateStorageKey(object.Key); err != nil {
+			return err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, object := range objects {
+		if s.closed {
+			return fmt.Errorf("failed to store object %s: archive is closed", object.Key)
+		}
+		if s.err != nil {
+			return fmt.Errorf("failed to store object %s: archive failed earlier: %w", object.Key, s.err)
+		}
+
+		info := ObjectInfo{Key: object.Key, ModTime: time.Now(), Metadata: copyMetadata(object.Metadata)}
+		var written bool
+		var err error
+		if s.tar != nil {
+			info.Size, written, err = s.putTar(info, object.Body)
+		} else {
+			info.Size, written, err = s.putZip(info, object.Body)
+		}
+		if err != nil {
+			err = fmt.Errorf("failed to store object %s: %w", object.Key, err)
+			if written || i > 0 {
+				s.err = err
+			}
+			return err
+		}
+
+		s.entries[object.Key] = info
+	}
+	return nil
+}
+
+// putTar writes a tar entry and reports whether anything was written. Tar headers need
+// the size up front: a body that implements io.Seeker, such as an *os.File, is measured
+// and streamed, other bodies are buffered.
+func (s *ArchiveStorage) putTar(info ObjectInfo, body io.Reader) (int64, bool, error) {
+	size, err := readerSize(body)
+	if err != nil {
+		return 0, false, err
+	}
+	if size < 0 {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return 0, false, err
+		}
+		body, size = bytes.NewReader(data), int64(len(data))
+	}
+
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     info.Key,
+		Size:     size,
+		Mode:     0644,
+		ModTime:  info.ModTime,
+		Format:   tar.FormatPAX,
+	}
+	if len(info.Metadata) > 0 {
+		header.PAXRecords = make(map[string]string, len(info.Metadata))
+		for key, value := range info.Metadata {
+			header.PAXRecords[paxMetadataPrefix+key] = value
+		}
+	}
+
+	if err := s.tar.WriteHeader(header); err != nil {
+		return 0, true, err
+	}
+	n, err := io.Copy(s.tar, body)
+	if err != nil {
+		return n, true, err
+	}
+	if n != size {
+		return n, true, fmt.Errorf("body changed while it was written: read %d of %d bytes", n, size)
+	}
+	return n, true, s.tar.Flush()
+}
+
+// readerSize returns the number of bytes left in a seekable reader, or -1 if the
+// reader cannot seek.
+func readerSize(r io.Reader) (int64, error) {
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		return -1, nil
+	}
+	offset, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := seeker.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - offset, nil
+}
+
+// putZip writes a zip entry, streaming the body, and reports whether anything was written.
+func (s *ArchiveStorage) putZip(info ObjectInfo, body io.Reader) (int64, bool, error) {
+	header := &zip.FileHeader{
+		Name:     info.Key,
+		Method:   zip.Deflate,
+		Modified: info.ModTime,
+	}
+	if len(info.Metadata) > 0 {
+		comment, err := json.Marshal(info.Metadata)
+		if err != nil {
+			return 0, false, err
+		}
+		header.Comment = string(comment)
+	}
+
+	w, err := s.zip.CreateHeader(header)
+	if err != nil {
+		return 0, true, err
+	}
+	n, err := io.Copy(w, body)
+	return n, true, err
+}
+
+// Get is not supported by archives, which are write-only; the error matches errors.ErrUnsupported.
+func (s *ArchiveStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	return nil, nil, fmt.Errorf("failed to get object %s from archive: %w", key, errors.ErrUnsupported)
+}
+
+// Exists reports whether an object has been written to the archive.
+func (s *ArchiveStorage) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.entries[key]
+	return ok, nil
+}
+
+// List describes the objects written to the archive whose keys start with prefix.
+func (s *ArchiveStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var objects []ObjectInfo
+	for key, info := range s.entries {
+		if strings.HasPrefix(key, prefix) {
+			info.Metadata = copyMetadata(info.Metadata)
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// Close finishes the archive. It does not close the underlying writer. If the archive
+// failed (see ArchiveStorage), the archive is finished but Close returns the failure.
+func (s *ArchiveStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	if !s.closed {
+		s.closed = true
+		if s.tar != nil {
+			err = s.tar.Close()
+		} else {
+			err = s.zip.Close()
+		}
+	}
+	if s.err != nil {
+		return fmt.Errorf("archive holds a partial filing: %w", s.err)
+	}
+	return err
+}
@@ -0,0 +1,213 @@
+package sec
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileSystemStorage stores objects as files below a root directory, with the key as
+// the relative path. Files are written atomically, and PutBatch makes a filing's
+// directory appear only once all of its documents are written. Object metadata is
+// not persisted; use a Manifest to keep the provenance of local files.
+type FileSystemStorage struct {
+	root string
+}
+
+// NewFileSystemStorage creates a storage that keeps objects below the given directory.
+//
+// Parameters:
+//   - root: The directory to store objects in (e.g., the download folder)
+//
+// Returns:
+//   - A new FileSystemStorage instance
+//
+// Example: NewFileSystemStorage("downloads")
+func NewFileSystemStorage(root string) *FileSystemStorage {
+	return &FileSystemStorage{root: root}
+}
+
+// Path returns the location of the file that stores an object.
+func (s *FileSystemStorage) Path(key string) string {
+	return filepath.Join(s.root, filepath.FromSlash(key))
+}
+
+// Put stores an object in a file, replacing any existing file atomically.
+func (s *FileSystemStorage) Put(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	if err := ValidateStorageKey(key); err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := writeFileAtomic(s.Path(key), body)
+	return err
+}
+
+// PutBatch stores several objects. If all of them belong to the same directory and that
+// directory does not exist yet, they are written to a hidden staging directory that is
+// then renamed into place, so the directory never appears partially written.
+func (s *FileSystemStorage) PutBatch(ctx context.Context, objects []StorageObject) error {
+	if len(objects) == 0 {
+		return nil
+	}
+	for _, object := range objects {
+		if err := ValidateStorageKey(object.Key); err != nil {
+			return err
+		}
+	}
+
+	// Objects spread over several directories are stored one at a time
+	dir := path.Dir(objects[0].Key)
+	for _, object := range objects[1:] {
+		if path.Dir(object.Key) != dir {
+			for _, object := range objects {
+				if err := s.Put(ctx, object.Key, object.Body, object.Metadata); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	// Write the objects to a staging directory and move them into place
+	stage, err := newFilingStage(spoolRoot(s), s.Path(dir))
+	if err != nil {
+		return err
+	}
+	defer stage.discard()
+
+	paths := make([]string, 0, len(objects))
+	for _, object := range objects {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		objectPath := s.Path(object.Key)
+		stagedPath, err := stage.stagedPath(objectPath)
+		if err != nil {
+			return err
+		}
+		if _, err := writeFileAtomic(stagedPath, object.Body); err != nil {
+			return err
+		}
+		paths = append(paths, objectPath)
+	}
+
+	return stage.commit(paths)
+}
+
+// Get opens the file that stores an object.
+func (s *FileSystemStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := ValidateStorageKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	file, err := os.Open(s.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, fmt.Errorf("object %s: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open object %s: %w", key, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat object %s: %w", key, err)
+	}
+	if !info.Mode().IsRegular() {
+		file.Close()
+		return nil, nil, fmt.Errorf("object %s: %w", key, ErrNotFound)
+	}
+
+	return file, &ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Exists reports whether a regular file stores the object.
+func (s *FileSystemStorage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ValidateStorageKey(key); err != nil {
+		return false, err
+	}
+
+	info, err := os.Stat(s.Path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat object %s: %w", key, err)
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+// List describes the files whose keys start with prefix. Staging directories, temporary
+// files of writes in progress and the manifest of the download folder are not listed.
+func (s *FileSystemStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	// Only walk the directory containing the prefix
+	start := s.root
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		start = s.Path(prefix[:i])
+	}
+
+	var objects []ObjectInfo
+	err := filepath.WalkDir(start, func(walkPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if isTemporaryName(entry.Name()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.root, walkPath)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) || key == manifestKey {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Key: key, Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// isTemporaryName reports whether a file or directory name is one of the hidden
+// temporary names used by writeFileAtomic and removeStaleLock, or the spool directory
+// that holds spools and staging directories.
+func isTemporaryName(name string) bool {
+	if name == spoolDirName {
+		return true
+	}
+	return strings.HasPrefix(name, ".") && (strings.HasSuffix(name, ".tmp") || strings.HasSuffix(name, ".partial"))
+}
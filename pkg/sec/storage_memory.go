@@ -0,0 +1,113 @@
+package sec
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryObject is an object kept by MemoryStorage.
+type memoryObject struct {
+	data []byte
+	info ObjectInfo
+}
+
+// MemoryStorage keeps objects in memory. It is useful for tests and for pipelines
+// that process filings without touching the disk.
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+// NewMemoryStorage creates an empty in-memory storage.
+//
+// Returns:
+//   - A new MemoryStorage instance
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]memoryObject)}
+}
+
+// Put stores a copy of the object's contents and metadata.
+func (s *MemoryStorage) Put(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	return s.PutBatch(ctx, []StorageObject{{Key: key, Body: body, Metadata: metadata}})
+}
+
+// PutBatch stores several objects; they become visible together.
+func (s *MemoryStorage) PutBatch(ctx context.Context, objects []StorageObject) error {
+	stored := make([]memoryObject, 0, len(objects))
+	for _, object := range objects {
+		if err := ValidateStorageKey(object.Key); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		data, err := io.ReadAll(object.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read object %s: %w", object.Key, err)
+		}
+		stored = append(stored, memoryObject{
+			data: data,
+			info: ObjectInfo{
+				Key:      object.Key,
+				Size:     int64(len(data)),
+				ModTime:  time.Now(),
+				Metadata: copyMetadata(object.Metadata),
+			},
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, object := range stored {
+		s.objects[object.info.Key] = object
+	}
+	return nil
+}
+
+// Get returns the contents and description of an object.
+func (s *MemoryStorage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	object, ok := s.objects[key]
+	if !ok {
+		return nil, nil, fmt.Errorf("object %s: %w", key, ErrNotFound)
+	}
+	info := object.info
+	info.Metadata = copyMetadata(info.Metadata)
+	return io.NopCloser(bytes.NewReader(object.data)), &info, nil
+}
+
+// Exists reports whether an object exists.
+func (s *MemoryStorage) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+// List describes the objects whose keys start with prefix, sorted by key.
+func (s *MemoryStorage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var objects []ObjectInfo
+	for key, object := range s.objects {
+		if strings.HasPrefix(key, prefix) {
+			info := object.info
+			info.Metadata = copyMetadata(info.Metadata)
+			objects = append(objects, info)
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
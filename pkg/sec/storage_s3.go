@@ -0,0 +1,499 @@
+package sec
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// s3MetadataHeaderPrefix prefixes the headers that carry object metadata
+	s3MetadataHeaderPrefix = "X-Amz-Meta-"
+	// sigV4Algorithm is the signing algorithm of AWS Signature Version 4
+	sigV4Algorithm = "AWS4-HMAC-SHA256"
+	// sigV4TimeFormat is the format of the X-Amz-Date header
+	sigV4TimeFormat = "20060102T150405Z"
+	// emptyPayloadHash is the SHA-256 checksum of an empty payload
+	emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Config configures an S3Storage.
+type S3Config struct {
+	// Endpoint is the base URL of the S3-compatible service
+	// (e.g., "https://s3.us-east-1.amazonaws.com" or "http://localhost:9000")
+	Endpoint string
+	// Region is the region used to sign requests (e.g., "us-east-1")
+	Region string
+	// Bucket is the name of the bucket to store objects in
+	Bucket string
+	// Prefix is prepended to every key (optional, e.g., "raw/edgar")
+	Prefix string
+	// AccessKeyID is the access key used to sign requests
+	AccessKeyID string
+	// SecretAccessKey is the secret key used to sign requests
+	SecretAccessKey string
+	// SessionToken is the session token of temporary credentials (optional)
+	SessionToken string
+	// PathStyle addresses the bucket in the URL path (http://host/bucket/key) instead of
+	// the host name (http://bucket.host/key). Most S3-compatible services, such as MinIO,
+	// require path-style addressing.
+	PathStyle bool
+	// HTTPClient is the client used to make requests (defaults to a client that waits
+	// up to DefaultRequestTimeout for each response, without bounding transfers)
+	HTTPClient *http.Client
+}
+
+// S3Storage stores objects in a bucket of Amazon S3 or an S3-compatible service.
+// Requests are signed with AWS Signature Version 4. Object metadata is stored as
+// x-amz-meta-* headers; listings do not include it.
+type S3Storage struct {
+	config   S3Config
+	endpoint *url.URL
+	client   *http.Client
+	now      func() time.Time
+}
+
+// NewS3Storage creates a storage backed by an S3 bucket.
+//
+// Parameters:
+//   - config: The endpoint, bucket and credentials to use
+//
+// Returns:
+//   - A new S3Storage instance and nil error on success
+//   - nil and error if the configuration is invalid
+//
+// Example:
+//
+//	storage, err := NewS3Storage(S3Config{
+//		Endpoint:        "http://localhost:9000",
+//		Region:          "us-east-1",
+//		Bucket:          "filings",
+//		AccessKeyID:     "minioadmin",
+//		SecretAccessKey: "minioadmin",
+//		PathStyle:       true,
+//	})
+func NewS3Storage(config S3Config) (*S3Storage, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q: must be an absolute http(s) URL", config.Endpoint)
+	}
+	if config.Region == "" {
+		return nil, fmt.Errorf("invalid S3 configuration: region is required")
+	}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("invalid S3 configuration: bucket is required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("invalid S3 configuration: access key ID and secret access key are required")
+	}
+	config.Prefix = strings.Trim(config.Prefix, "/")
+
+	client := config.HTTPClient
+	if client == nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.ResponseHeaderTimeout = DefaultRequestTimeout
+		client = &http.Client{Transport: transport}
+	}
+
+	return &S3Storage{config: config, endpoint: endpoint, client: client, now: time.Now}, nil
+}
+
+// Put uploads an object. S3 requires the checksum and length of the body before the
+// upload starts: a body that implements io.Seeker, such as an *os.File, is read once to
+// compute them and then streamed from its current offset; other bodies are buffered.
+func (s *S3Storage) Put(ctx context.Context, key string, body io.Reader, metadata map[string]string) error {
+	if err := ValidateStorageKey(key); err != nil {
+		return err
+	}
+	payload, size, payloadHash, err := preparePayload(body)
+	if err != nil {
+		return fmt.Errorf("failed to read object %s: %w", key, err)
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, s.objectPath(key), nil, payload)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	for name, value := range metadata {
+		req.Header.Set(s3MetadataHeaderPrefix+name, value)
+	}
+	s.sign(req, payloadHash)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return fmt.Errorf("failed to put object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// PutBatch uploads several objects one at a time. If an upload fails, the objects this
+// batch created are deleted again, so that a filing is never left partly stored; objects
+// that existed before the batch keep their new contents. The deletes are sent even if
+// ctx was cancelled.
+func (s *S3Storage) PutBatch(ctx context.Context, objects []StorageObject) error {
+	var created []string
+	for _, object := range objects {
+		exists, err := s.Exists(ctx, object.Key)
+		if err == nil {
+			err = s.Put(ctx, object.Key, object.Body, object.Metadata)
+		}
+		if err != nil {
+			return errors.Join(err, s.deleteObjects(context.WithoutCancel(ctx), created))
+		}
+		if !exists {
+			created = append(created, object.Key)
+		}
+	}
+	return nil
+}
+
+// deleteObjects deletes objects one at a time, attempting every key.
+func (s *S3Storage) deleteObjects(ctx context.Context, keys []string) error {
+	var errs []error
+	for _, key := range keys {
+		if err := s.deleteObject(ctx, key); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// deleteObject removes an object. Deleting an object that does not exist succeeds.
+func (s *S3Storage) deleteObject(ctx context.Context, key string) error {
+	req, err := s.newRequest(ctx, http.MethodDelete, s.objectPath(key), nil, nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.do(req)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return fmt.Errorf("failed to delete object %s: %w", key, err)
+	}
+	if resp != nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// preparePayload returns a reader of the body with its size and hex-encoded SHA-256
+// checksum. Seekable bodies are hashed in one pass and rewound; others are buffered.
+func preparePayload(body io.Reader) (io.Reader, int64, string, error) {
+	hash := sha256.New()
+
+	seeker, ok := body.(io.Seeker)
+	if !ok {
+		data, err := io.ReadAll(io.TeeReader(body, hash))
+		if err != nil {
+			return nil, 0, "", err
+		}
+		return bytes.NewReader(data), int64(len(data)), hex.EncodeToString(hash.Sum(nil)), nil
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	size, err := io.Copy(hash, body)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return nil, 0, "", err
+	}
+	// The limit hides the body's Close from the HTTP client, which must not close the caller's file
+	return io.LimitReader(body, size), size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Get downloads an object.
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	if err := ValidateStorageKey(key); err != nil {
+		return nil, nil, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodGet, s.objectPath(key), nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get object %s: %w", key, err)
+	}
+	return resp.Body, s.objectInfo(key, resp), nil
+}
+
+// Exists reports whether an object exists, using a HEAD request.
+func (s *S3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	if err := ValidateStorageKey(key); err != nil {
+		return false, err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodHead, s.objectPath(key), nil, nil)
+	if err != nil {
+		return false, err
+	}
+	s.sign(req, emptyPayloadHash)
+
+	resp, err := s.do(req)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check object %s: %w", key, err)
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// listBucketResult is the response of the ListObjectsV2 operation.
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List describes the objects whose keys start with prefix, following continuation
+// tokens until the listing is complete.
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.fullKey(prefix)}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		req, err := s.newRequest(ctx, http.MethodGet, s.bucketPath(), query, nil)
+		if err != nil {
+			return nil, err
+		}
+		s.sign(req, emptyPayloadHash)
+
+		resp, err := s.do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, &DecodeError{Resource: "object listing", URL: req.URL.String(), Err: err}
+		}
+
+		for _, content := range result.Contents {
+			key := strings.TrimPrefix(content.Key, s.keyPrefix())
+			objects = append(objects, ObjectInfo{Key: key, Size: content.Size, ModTime: content.LastModified})
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Key < objects[j].Key
+	})
+	return objects, nil
+}
+
+// keyPrefix returns the prefix prepended to keys, with a trailing slash if not empty.
+func (s *S3Storage) keyPrefix() string {
+	if s.config.Prefix == "" {
+		return ""
+	}
+	return s.config.Prefix + "/"
+}
+
+// fullKey returns the key of an object in the bucket.
+func (s *S3Storage) fullKey(key string) string {
+	return s.keyPrefix() + key
+}
+
+// bucketPath returns the URL path of the bucket.
+func (s *S3Storage) bucketPath() string {
+	base := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.config.PathStyle {
+		return base + "/" + s.config.Bucket + "/"
+	}
+	return base + "/"
+}
+
+// objectPath returns the URL path of an object.
+func (s *S3Storage) objectPath(key string) string {
+	return s.bucketPath() + s.fullKey(key)
+}
+
+// newRequest builds a request to the bucket. The path is sent encoded exactly as it
+// is signed.
+func (s *S3Storage) newRequest(ctx context.Context, method, path string, query url.Values, body io.Reader) (*http.Request, error) {
+	u := *s.endpoint
+	if !s.config.PathStyle {
+		u.Host = s.config.Bucket + "." + u.Host
+	}
+	u.Path = path
+	u.RawPath = uriEncode(path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	return req, nil
+}
+
+// do sends a signed request and turns non-2xx responses into an *HTTPError.
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, &HTTPError{StatusCode: resp.StatusCode, URL: req.URL.String(), Snippet: readSnippet(resp)}
+	}
+	return resp, nil
+}
+
+// objectInfo describes an object from the headers of a GET or HEAD response.
+func (s *S3Storage) objectInfo(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{Key: key, Size: resp.ContentLength}
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		info.Size = size
+	}
+	if modTime, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.ModTime = modTime
+	}
+	for name, values := range resp.Header {
+		if metaName, ok := strings.CutPrefix(name, s3MetadataHeaderPrefix); ok && len(values) > 0 {
+			if info.Metadata == nil {
+				info.Metadata = make(map[string]string)
+			}
+			info.Metadata[strings.ToLower(metaName)] = values[0]
+		}
+	}
+	return info
+}
+
+// sign adds the AWS Signature Version 4 headers to a request. Every header already set
+// on the request, and the Host header, is signed.
+func (s *S3Storage) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format(sigV4TimeFormat)
+	scope := strings.Join([]string{now.Format("20060102"), s.config.Region, "s3", "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.config.SessionToken)
+	}
+
+	// Build the canonical headers
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.Join(values, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Build the canonical request and the string to sign
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		sigV4Algorithm,
+		amzDate,
+		scope,
+		hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+
+	// Derive the signing key and sign
+	key := hmacSHA256([]byte("AWS4"+s.config.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, s.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, s.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the given key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalQuery encodes query parameters sorted by name, as required by Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := append([]string(nil), query[name]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(name, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode percent-encodes every byte except unreserved characters, as required by
+// Signature Version 4. Slashes are kept unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,401 @@
+package sec
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// testStorageConformance checks the behavior every readable Storage must implement.
+func testStorageConformance(t *testing.T, storage Storage, keepsMetadata bool) {
+	t.Helper()
+	ctx := context.Background()
+
+	metadata := map[string]string{MetadataSourceURL: "https://www.sec.gov/a", MetadataSHA256: "abc"}
+	objects := map[string]string{
+		"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/index.html":   "index",
+		"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/primary.htm":  "primary",
+		"sec-edgar-filings/AAPL/8-K/0000320193-22-000002/index.html":    "8-K index",
+		"sec-edgar-filings/MSFT/10-K/0000789019-22-000001/index.html":   "msft index",
+		"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/details.html": "details",
+	}
+	for key, body := range objects {
+		if err := storage.Put(ctx, key, strings.NewReader(body), metadata); err != nil {
+			t.Fatalf("Put(%q) error = %v", key, err)
+		}
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		key := "sec-edgar-filings/AAPL/10-K/0000320193-22-000001/primary.htm"
+		body, info, err := storage.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		if string(data) != "primary" || info.Key != key || info.Size != int64(len("primary")) {
+			t.Errorf("Get() = %q, %+v", data, info)
+		}
+		if keepsMetadata && !reflect.DeepEqual(info.Metadata, metadata) {
+			t.Errorf("Get() metadata = %v, want %v", info.Metadata, metadata)
+		}
+	})
+
+	t.Run("Get missing", func(t *testing.T) {
+		_, _, err := storage.Get(ctx, "sec-edgar-filings/missing")
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("Get() error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		for key, want := range map[string]bool{
+			"sec-edgar-filings/AAPL/8-K/0000320193-22-000002/index.html": true,
+			"sec-edgar-filings/AAPL/8-K/0000320193-22-000002/other.html": false,
+			"sec-edgar-filings/AAPL/8-K":                                 false,
+		} {
+			got, err := storage.Exists(ctx, key)
+			if err != nil || got != want {
+				t.Errorf("Exists(%q) = %v, %v, want %v", key, got, err, want)
+			}
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		got, err := storage.List(ctx, "sec-edgar-filings/AAPL/10-K/")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var keys []string
+		for _, info := range got {
+			keys = append(keys, info.Key)
+		}
+		want := []string{
+			"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/details.html",
+			"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/index.html",
+			"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/primary.htm",
+		}
+		if !reflect.DeepEqual(keys, want) {
+			t.Errorf("List() keys = %v, want %v", keys, want)
+		}
+	})
+
+	t.Run("Put replaces", func(t *testing.T) {
+		key := "sec-edgar-filings/MSFT/10-K/0000789019-22-000001/index.html"
+		if err := storage.Put(ctx, key, strings.NewReader("replaced"), nil); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		body, _, err := storage.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		defer body.Close()
+		if data, _ := io.ReadAll(body); string(data) != "replaced" {
+			t.Errorf("Get() = %q, want %q", data, "replaced")
+		}
+	})
+
+	t.Run("Invalid keys", func(t *testing.T) {
+		for _, key := range []string{"", "/abs", "../escape", "a/../../b", "a\\b", "a//b"} {
+			if err := storage.Put(ctx, key, strings.NewReader("x"), nil); err == nil {
+				t.Errorf("Put(%q) error = nil, want invalid key", key)
+			}
+		}
+	})
+}
+
+func TestFileSystemStorage(t *testing.T) {
+	root := t.TempDir()
+	storage := NewFileSystemStorage(root)
+	testStorageConformance(t, storage, false)
+
+	t.Run("Path", func(t *testing.T) {
+		want := filepath.Join(root, "sec-edgar-filings", "AAPL", "index.html")
+		if got := storage.Path("sec-edgar-filings/AAPL/index.html"); got != want {
+			t.Errorf("Path() = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("PutBatch", func(t *testing.T) {
+		objects := []StorageObject{
+			{Key: "batch/filing/a.html", Body: strings.NewReader("a")},
+			{Key: "batch/filing/b.html", Body: strings.NewReader("b")},
+		}
+		if err := storage.PutBatch(context.Background(), objects); err != nil {
+			t.Fatalf("PutBatch() error = %v", err)
+		}
+		entries, err := os.ReadDir(filepath.Join(root, "batch"))
+		if err != nil {
+			t.Fatalf("os.ReadDir() error = %v", err)
+		}
+		if len(entries) != 1 || entries[0].Name() != "filing" {
+			t.Errorf("PutBatch() left %v, want only the filing directory", entries)
+		}
+	})
+
+	t.Run("List skips temporary files", func(t *testing.T) {
+		dir := filepath.Join(root, "tmp", "filing")
+		os.MkdirAll(filepath.Join(root, "tmp", ".filing.123.partial"), 0755)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, ".index.html.123.tmp"), []byte("x"), 0644)
+		os.WriteFile(filepath.Join(dir, "index.html"), []byte("x"), 0644)
+
+		got, err := storage.List(context.Background(), "tmp/")
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(got) != 1 || got[0].Key != "tmp/filing/index.html" {
+			t.Errorf("List() = %+v, want only tmp/filing/index.html", got)
+		}
+	})
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorageConformance(t, NewMemoryStorage(), true)
+}
+
+func TestArchiveStorage(t *testing.T) {
+	metadata := map[string]string{MetadataSourceURL: "https://www.sec.gov/a", MetadataSHA256: "abc"}
+	want := map[string]string{
+		"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/index.html":  "index",
+		"sec-edgar-filings/AAPL/10-K/0000320193-22-000001/primary.htm": "primary",
+	}
+
+	write := func(t *testing.T, storage *ArchiveStorage) {
+		t.Helper()
+		ctx := context.Background()
+		objects := []StorageObject{
+			{Key: "sec-edgar-filings/AAPL/10-K/0000320193-22-000001/index.html", Body: strings.NewReader("index"), Metadata: metadata},
+			{Key: "sec-edgar-filings/AAPL/10-K/0000320193-22-000001/primary.htm", Body: strings.NewReader("primary"), Metadata: metadata},
+		}
+		if err := putObjects(ctx, storage, objects); err != nil {
+			t.Fatalf("putObjects() error = %v", err)
+		}
+		if ok, _ := storage.Exists(ctx, objects[0].Key); !ok {
+			t.Errorf("Exists(%q) = false, want true", objects[0].Key)
+		}
+		if list, _ := storage.List(ctx, "sec-edgar-filings/"); len(list) != 2 {
+			t.Errorf("List() = %+v, want 2 objects", list)
+		}
+		if _, _, err := storage.Get(ctx, objects[0].Key); !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Get() error = %v, want ErrUnsupported", err)
+		}
+		if err := storage.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := storage.Put(ctx, "late", strings.NewReader("x"), nil); err == nil {
+			t.Errorf("Put() after Close() error = nil, want error")
+		}
+	}
+
+	t.Run("Tar", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, NewTarStorage(&buf))
+
+		reader := tar.NewReader(&buf)
+		got := make(map[string]string)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("tar Next() error = %v", err)
+			}
+			data, _ := io.ReadAll(reader)
+			got[header.Name] = string(data)
+			if url := header.PAXRecords[paxMetadataPrefix+MetadataSourceURL]; url != metadata[MetadataSourceURL] {
+				t.Errorf("entry %s source URL = %q, want %q", header.Name, url, metadata[MetadataSourceURL])
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("tar entries = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Tar with file and unseekable bodies", func(t *testing.T) {
+		file, err := os.CreateTemp(t.TempDir(), "spool")
+		if err != nil {
+			t.Fatalf("os.CreateTemp() error = %v", err)
+		}
+		defer file.Close()
+		file.WriteString("skipped file body")
+		file.Seek(int64(len("skipped ")), io.SeekStart)
+
+		var buf bytes.Buffer
+		storage := NewTarStorage(&buf)
+		ctx := context.Background()
+		if err := storage.Put(ctx, "file", file, nil); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		if err := storage.Put(ctx, "unseekable", io.MultiReader(strings.NewReader("unseekable "), strings.NewReader("body")), nil); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+		storage.Close()
+
+		reader := tar.NewReader(&buf)
+		got := make(map[string]string)
+		for {
+			header, err := reader.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("tar Next() error = %v", err)
+			}
+			data, _ := io.ReadAll(reader)
+			got[header.Name] = string(data)
+		}
+		if want := map[string]string{"file": "file body", "unseekable": "unseekable body"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("tar entries = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Failed batch marks the archive failed", func(t *testing.T) {
+		var buf bytes.Buffer
+		storage := NewTarStorage(&buf)
+		ctx := context.Background()
+
+		// A batch failing before anything was written leaves the archive usable
+		lost := []StorageObject{{Key: "lost", Body: iotest.ErrReader(errors.New("spool file lost"))}}
+		if err := storage.PutBatch(ctx, lost); err == nil {
+			t.Fatalf("PutBatch() error = nil, want error")
+		}
+		if err := storage.Put(ctx, "first", strings.NewReader("first"), nil); err != nil {
+			t.Fatalf("Put() error = %v", err)
+		}
+
+		// A batch failing part-way leaves a partial filing, reported by every later call
+		partial := []StorageObject{
+			{Key: "index.html", Body: strings.NewReader("index")},
+			{Key: "primary.htm", Body: iotest.ErrReader(errors.New("spool file lost"))},
+		}
+		if err := storage.PutBatch(ctx, partial); err == nil {
+			t.Fatalf("PutBatch() error = nil, want error")
+		}
+		if err := storage.Put(ctx, "later", strings.NewReader("later"), nil); err == nil {
+			t.Errorf("Put() after a failed batch error = nil, want error")
+		}
+		if err := storage.Close(); err == nil {
+			t.Errorf("Close() after a failed batch error = nil, want error")
+		}
+	})
+
+	t.Run("Zip", func(t *testing.T) {
+		var buf bytes.Buffer
+		write(t, NewZipStorage(&buf))
+
+		reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		if err != nil {
+			t.Fatalf("zip.NewReader() error = %v", err)
+		}
+		got := make(map[string]string)
+		for _, file := range reader.File {
+			rc, err := file.Open()
+			if err != nil {
+				t.Fatalf("Open(%q) error = %v", file.Name, err)
+			}
+			data, _ := io.ReadAll(rc)
+			rc.Close()
+			got[file.Name] = string(data)
+
+			var gotMetadata map[string]string
+			if err := json.Unmarshal([]byte(file.Comment), &gotMetadata); err != nil || !reflect.DeepEqual(gotMetadata, metadata) {
+				t.Errorf("entry %s metadata = %v (%v), want %v", file.Name, gotMetadata, err, metadata)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("zip entries = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestFetchAndSaveFilingsWithStorage(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm"},
+		}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+		w.Header().Set("ETag", `"abc123"`)
+		w.Write([]byte("content of " + r.URL.Path))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	storage := NewMemoryStorage()
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+		Storage:        storage,
+	}
+	client := newTestClient(t, server)
+
+	report, err := FetchAndSaveFilings(metadata, client)
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+
+	ctx := context.Background()
+	for _, doc := range report.Filings[0].Documents {
+		wantKey := "sec-edgar-filings/AAPL/10-K/0000320193-22-000001/" + doc.Name
+		if doc.Key != wantKey || doc.Path != "" {
+			t.Errorf("DocumentResult %s Key, Path = %q, %q, want %q, \"\"", doc.Kind, doc.Key, doc.Path, wantKey)
+		}
+
+		body, info, err := storage.Get(ctx, doc.Key)
+		if err != nil {
+			t.Fatalf("Get(%q) error = %v", doc.Key, err)
+		}
+		data, _ := io.ReadAll(body)
+		body.Close()
+		if !strings.HasPrefix(string(data), "content of ") {
+			t.Errorf("Get(%q) = %q", doc.Key, data)
+		}
+		if info.Metadata[MetadataSourceURL] != doc.URL || info.Metadata[MetadataSHA256] != doc.SHA256 ||
+			info.Metadata[MetadataAccessionNumber] != "0000320193-22-000001" || info.Metadata[MetadataETag] != `"abc123"` {
+			t.Errorf("Get(%q) metadata = %v", doc.Key, info.Metadata)
+		}
+	}
+
+	// Nothing is written to the download folder
+	if entries, _ := os.ReadDir(folder); len(entries) != 0 {
+		t.Errorf("download folder contains %v, want nothing", entries)
+	}
+
+	// Existing documents are detected in the storage
+	metadata.SkipExisting = true
+	report, err = FetchAndSaveFilings(metadata, client)
+	if err != nil {
+		t.Fatalf("FetchAndSaveFilings() error = %v", err)
+	}
+	if report.SkippedCount() != 1 {
+		t.Errorf("SkippedCount() = %d, want 1", report.SkippedCount())
+	}
+}
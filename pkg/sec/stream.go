@@ -0,0 +1,465 @@
+package sec
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DocumentInfo describes a document downloaded from the SEC.
+type DocumentInfo struct {
+	// URL is the URL the document was downloaded from
+	URL string
+	// Size is the number of bytes of the document, after decompression
+	Size int64
+	// SHA256 is the hex-encoded SHA-256 checksum of the document
+	SHA256 string
+	// LastModified is the Last-Modified header returned by the SEC (may be empty)
+	LastModified string
+	// ETag is the ETag header returned by the SEC (may be empty)
+	ETag string
+}
+
+// DocumentStream is the body of a document being downloaded from the SEC.
+// Reading it streams the response, decompressing it if needed, and the size and
+// SHA-256 checksum of the bytes read are computed on the fly. It must be closed.
+type DocumentStream struct {
+	url          string
+	lastModified string
+	etag         string
+
+	resp *http.Response
+	body io.ReadCloser
+	hash hash.Hash
+	size int64
+}
+
+// newDocumentStream wraps a successful response to a document request.
+func newDocumentStream(uri string, resp *http.Response) (*DocumentStream, error) {
+	body, err := getResponseBody(resp)
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+
+	return &DocumentStream{
+		url:          uri,
+		lastModified: resp.Header.Get("Last-Modified"),
+		etag:         resp.Header.Get("ETag"),
+		resp:         resp,
+		body:         body,
+		hash:         sha256.New(),
+	}, nil
+}
+
+// Read reads the next bytes of the document.
+func (d *DocumentStream) Read(p []byte) (int, error) {
+	n, err := d.body.Read(p)
+	d.hash.Write(p[:n])
+	d.size += int64(n)
+	return n, err
+}
+
+// Close closes the response body.
+func (d *DocumentStream) Close() error {
+	if d.body != d.resp.Body {
+		d.body.Close()
+	}
+	return d.resp.Body.Close()
+}
+
+// Info describes the document. Size and SHA256 cover the bytes read so far, so they
+// describe the whole document once the stream has been read to the end.
+func (d *DocumentStream) Info() DocumentInfo {
+	return DocumentInfo{
+		URL:          d.url,
+		Size:         d.size,
+		SHA256:       hex.EncodeToString(d.hash.Sum(nil)),
+		LastModified: d.lastModified,
+		ETag:         d.etag,
+	}
+}
+
+// DownloadFilingStream starts downloading a document from the SEC EDGAR database
+// without reading its body.
+//
+// Parameters:
+//   - uri: The URI of the document to download
+//
+// Returns:
+//   - A stream of the document's contents, which must be closed, and nil error on success
+//   - nil and error on failure
+func (s *SECClient) DownloadFilingStream(uri string) (*DocumentStream, error) {
+	return s.DownloadFilingStreamWithContext(context.Background(), uri)
+}
+
+// DownloadFilingStreamWithContext starts downloading a document from the SEC EDGAR
+// database without reading its body. The request is retried according to the client's
+// retry policy until a response is received; failures while reading the stream are
+// returned by Read. The context also bounds reading the stream.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - uri: The URI of the document to download
+//
+// Returns:
+//   - A stream of the document's contents, which must be closed, and nil error on success
+//   - nil and error on failure
+//
+// Example:
+//
+//	stream, err := client.DownloadFilingStreamWithContext(ctx, uri)
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	_, err = io.Copy(file, stream)
+//	fmt.Println(stream.Info().SHA256)
+func (s *SECClient) DownloadFilingStreamWithContext(ctx context.Context, uri string) (*DocumentStream, error) {
+	resp, err := s.callSECWithContext(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	return newDocumentStream(uri, resp)
+}
+
+// DownloadFilingTo downloads a document from the SEC EDGAR database and writes it to w.
+//
+// Parameters:
+//   - w: The writer the document is written to
+//   - uri: The URI of the document to download
+//
+// Returns:
+//   - The description of the document and nil error on success
+//   - nil and error on failure; part of the document may have been written to w
+func (s *SECClient) DownloadFilingTo(w io.Writer, uri string) (*DocumentInfo, error) {
+	return s.DownloadFilingToWithContext(context.Background(), w, uri)
+}
+
+// DownloadFilingToWithContext downloads a document from the SEC EDGAR database and
+// writes it to w as it is received, computing its size and SHA-256 checksum on the fly.
+// Only a small buffer is held in memory, whatever the size of the document.
+// If the connection is lost part way through, the rest of the document is requested
+// with a Range header according to the client's retry policy, provided the SEC sent an
+// ETag or Last-Modified validator proving that the document has not changed meanwhile.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - w: The writer the document is written to
+//   - uri: The URI of the document to download
+//
+// Returns:
+//   - The description of the document and nil error on success
+//   - nil and error on failure; part of the document may have been written to w
+func (s *SECClient) DownloadFilingToWithContext(ctx context.Context, w io.Writer, uri string) (*DocumentInfo, error) {
+	var stream *DocumentStream
+	defer func() {
+		if stream != nil {
+			stream.Close()
+		}
+	}()
+
+	err := s.retry(ctx, uri, func() error {
+		// Request the document, or the rest of it after a failed attempt
+		if stream == nil {
+			resp, err := s.doRequest(ctx, uri, nil)
+			if err != nil {
+				return err
+			}
+			if stream, err = newDocumentStream(uri, resp); err != nil {
+				return err
+			}
+		} else if err := s.reopenStream(ctx, stream); err != nil {
+			return err
+		}
+
+		if _, err := io.Copy(w, stream); err != nil {
+			return fmt.Errorf("failed to download %s: %w", uri, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	info := stream.Info()
+	return &info, nil
+}
+
+// reopenStream continues a stream whose body failed, requesting the rest of the document
+// after the bytes read so far. It fails, without retry, if the document cannot be shown
+// to be unchanged. A stream that has read nothing requests the document again.
+func (s *SECClient) reopenStream(ctx context.Context, d *DocumentStream) error {
+	partial := DocumentInfo{ETag: d.etag, LastModified: d.lastModified}
+	header := http.Header{}
+	if d.size > 0 {
+		validator := ifRangeValidator(partial)
+		if validator == "" {
+			return fmt.Errorf("failed to resume %s after %d bytes: no validator to check that it is unchanged", d.url, d.size)
+		}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", d.size))
+		header.Set("If-Range", validator)
+		// Byte ranges refer to the uncompressed document
+		header.Set("Accept-Encoding", "identity")
+	}
+
+	resp, err := s.doRequest(ctx, d.url, header)
+	if err != nil {
+		return err
+	}
+	continues := resp.StatusCode == http.StatusPartialContent && continuesDocument(resp, d.size, partial)
+	if d.size > 0 && !continues {
+		resp.Body.Close()
+		return fmt.Errorf("failed to resume %s after %d bytes: the document has changed", d.url, d.size)
+	}
+
+	body, err := getResponseBody(resp)
+	if err != nil {
+		return err
+	}
+	d.Close()
+	d.resp, d.body = resp, body
+	return nil
+}
+
+// ResumeFilingTo downloads a document into file, resuming an earlier download.
+//
+// Parameters:
+//   - file: The file holding the part of the document downloaded so far (may be empty)
+//   - uri: The URI of the document to download
+//   - partial: The description of the earlier download, for its ETag and Last-Modified validators
+//
+// Returns:
+//   - The description of the whole document and nil error on success
+//   - The description of the part of the document in the file and error on failure
+func (s *SECClient) ResumeFilingTo(file *os.File, uri string, partial DocumentInfo) (*DocumentInfo, error) {
+	return s.ResumeFilingToWithContext(context.Background(), file, uri, partial)
+}
+
+// ResumeFilingToWithContext downloads a document into file, resuming an earlier download
+// that was interrupted. The file holds the beginning of the document, and partial carries
+// the ETag and Last-Modified headers of the earlier response. The rest of the document is
+// requested with a Range header conditioned on these validators with If-Range, so it is
+// only sent if the document has not changed since. If the document has changed, if the
+// SEC ignores the range, or if there is nothing to resume, the whole document is
+// downloaded and the file is overwritten from the start.
+// The size and SHA-256 checksum returned cover the whole document, including the part
+// that was already in the file. A download cut short is resumed again, from the bytes
+// in the file, according to the client's retry policy.
+//
+// Parameters:
+//   - ctx: The context controlling cancellation and deadlines
+//   - file: The file holding the part of the document downloaded so far (may be empty)
+//   - uri: The URI of the document to download
+//   - partial: The description of the earlier download, for its ETag and Last-Modified validators
+//
+// Returns:
+//   - The description of the whole document and nil error on success
+//   - The description of the part of the document in the file and error on failure;
+//     it can be passed as partial to a later call to resume again
+//
+// Example:
+//
+//	info, err := client.ResumeFilingToWithContext(ctx, file, uri, sec.DocumentInfo{})
+//	for err != nil && ctx.Err() == nil {
+//		info, err = client.ResumeFilingToWithContext(ctx, file, uri, *info)
+//	}
+func (s *SECClient) ResumeFilingToWithContext(ctx context.Context, file *os.File, uri string, partial DocumentInfo) (*DocumentInfo, error) {
+	return s.resumeDownload(ctx, file, uri, partial, nil)
+}
+
+// resumeDownload implements ResumeFilingToWithContext. If started is not nil, it is called
+// with the description of every response before its body is written, so that its
+// validators can be recorded even if the download is interrupted. A download cut short
+// is resumed from the bytes in the file according to the client's retry policy.
+func (s *SECClient) resumeDownload(ctx context.Context, file *os.File, uri string, partial DocumentInfo, started func(DocumentInfo) error) (*DocumentInfo, error) {
+	info := &DocumentInfo{URL: uri}
+	err := s.retry(ctx, uri, func() error {
+		var err error
+		info, err = s.resumeOnce(ctx, file, uri, partial, started)
+		partial = *info
+		return err
+	})
+	return info, err
+}
+
+// resumeOnce makes a single attempt of resumeDownload.
+func (s *SECClient) resumeOnce(ctx context.Context, file *os.File, uri string, partial DocumentInfo, started func(DocumentInfo) error) (*DocumentInfo, error) {
+	// Step 1: Find how much of the document the file holds
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return &DocumentInfo{URL: uri}, fmt.Errorf("failed to seek %s: %w", file.Name(), err)
+	}
+	info := DocumentInfo{URL: uri, Size: offset, LastModified: partial.LastModified, ETag: partial.ETag}
+
+	// Step 2: Request the rest of the document, or all of it
+	stream, err := s.openResumedStream(ctx, file, uri, offset, partial)
+	if err != nil {
+		return &info, err
+	}
+	defer stream.Close()
+
+	info = stream.Info()
+	if started != nil {
+		if err := started(info); err != nil {
+			return &info, err
+		}
+	}
+
+	// Step 3: Append the response to the file
+	_, err = io.Copy(file, stream)
+	info = stream.Info()
+	if err != nil {
+		return &info, fmt.Errorf("failed to download %s: %w", uri, err)
+	}
+	return &info, nil
+}
+
+// openResumedStream requests the part of a document that follows the first offset bytes
+// held in file. If the range is granted, the returned stream continues the document and
+// its checksum covers the bytes in the file; otherwise the file is truncated and the
+// stream holds the whole document.
+func (s *SECClient) openResumedStream(ctx context.Context, file *os.File, uri string, offset int64, partial DocumentInfo) (*DocumentStream, error) {
+	if validator := ifRangeValidator(partial); offset > 0 && validator != "" {
+		header := http.Header{}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		header.Set("If-Range", validator)
+		// Byte ranges refer to the uncompressed document
+		header.Set("Accept-Encoding", "identity")
+
+		resp, err := s.doRequest(ctx, uri, header)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			// The document has changed, or the SEC ignored the range
+			return restartStream(file, uri, resp)
+		case resp.StatusCode == http.StatusPartialContent && continuesDocument(resp, offset, partial):
+			return continueStream(file, uri, resp, offset, partial)
+		case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && completesDocument(resp, offset):
+			// The file already holds the whole document
+			resp.Body.Close()
+			resp.Body = http.NoBody
+			return continueStream(file, uri, resp, offset, partial)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := s.doRequest(ctx, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	return restartStream(file, uri, resp)
+}
+
+// restartStream truncates file and returns the stream of the whole document.
+func restartStream(file *os.File, uri string, resp *http.Response) (*DocumentStream, error) {
+	if err := file.Truncate(0); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to truncate %s: %w", file.Name(), err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to seek %s: %w", file.Name(), err)
+	}
+	return newDocumentStream(uri, resp)
+}
+
+// continueStream returns the stream of the rest of a document whose first offset bytes
+// are held in file, leaving the file positioned at its end.
+func continueStream(file *os.File, uri string, resp *http.Response, offset int64, partial DocumentInfo) (*DocumentStream, error) {
+	stream, err := newDocumentStream(uri, resp)
+	if err != nil {
+		return nil, err
+	}
+	if stream.etag == "" {
+		stream.etag = partial.ETag
+	}
+	if stream.lastModified == "" {
+		stream.lastModified = partial.LastModified
+	}
+
+	// Include the bytes already downloaded in the checksum
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to seek %s: %w", file.Name(), err)
+	}
+	if _, err := io.CopyN(stream.hash, file, offset); err != nil {
+		stream.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", file.Name(), err)
+	}
+	stream.size = offset
+	return stream, nil
+}
+
+// ifRangeValidator returns the If-Range value of an earlier download: its ETag if it is
+// strong, or its Last-Modified date. It returns an empty string if neither is usable.
+func ifRangeValidator(partial DocumentInfo) string {
+	if partial.ETag != "" && !strings.HasPrefix(partial.ETag, "W/") {
+		return partial.ETag
+	}
+	return partial.LastModified
+}
+
+// continuesDocument reports whether a 206 response holds the rest of a document from
+// offset, unencoded and with the same ETag as the earlier download.
+func continuesDocument(resp *http.Response, offset int64, partial DocumentInfo) bool {
+	if encoding := resp.Header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return false
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && partial.ETag != "" && etag != partial.ETag {
+		return false
+	}
+	first, _, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	return ok && first == offset
+}
+
+// completesDocument reports whether a 416 response says the document is offset bytes long.
+func completesDocument(resp *http.Response, offset int64) bool {
+	first, complete, ok := parseContentRange(resp.Header.Get("Content-Range"))
+	return ok && first < 0 && complete == offset
+}
+
+// parseContentRange parses a Content-Range header of the form "bytes first-last/complete"
+// or "bytes */complete". It returns -1 for an unknown first byte or complete length.
+func parseContentRange(value string) (first, complete int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	byteRange, length, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+
+	complete = -1
+	if length != "*" {
+		n, err := strconv.ParseInt(length, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, false
+		}
+		complete = n
+	}
+
+	if byteRange == "*" {
+		return -1, complete, complete >= 0
+	}
+	firstPos, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, false
+	}
+	first, err := strconv.ParseInt(firstPos, 10, 64)
+	if err != nil || first < 0 {
+		return 0, 0, false
+	}
+	return first, complete, true
+}
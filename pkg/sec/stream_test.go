@@ -0,0 +1,774 @@
+package sec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDownloadFilingStream(t *testing.T) {
+	content := strings.Repeat("filing content ", 10000)
+	checksum := sha256.Sum256([]byte(content))
+
+	tests := []struct {
+		name            string
+		statusCode      int
+		contentEncoding string
+		wantErr         bool
+	}{
+		{name: "Plain response", statusCode: http.StatusOK},
+		{name: "Gzipped response", statusCode: http.StatusOK, contentEncoding: "gzip"},
+		{name: "HTTP error", statusCode: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("ETag", `"v1"`)
+				w.Header().Set("Last-Modified", "Fri, 28 Oct 2022 06:01:00 GMT")
+				if tt.contentEncoding == "gzip" {
+					w.Header().Set("Content-Encoding", "gzip")
+					w.WriteHeader(tt.statusCode)
+					gz := gzip.NewWriter(w)
+					gz.Write([]byte(content))
+					gz.Close()
+					return
+				}
+				w.WriteHeader(tt.statusCode)
+				w.Write([]byte(content))
+			}))
+			defer server.Close()
+
+			client := NewSECClient("TestCompany", "test@example.com")
+			stream, err := client.DownloadFilingStream(server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DownloadFilingStream() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer stream.Close()
+
+			data, err := io.ReadAll(stream)
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if string(data) != content {
+				t.Errorf("DownloadFilingStream() read %d bytes, want %d", len(data), len(content))
+			}
+
+			want := DocumentInfo{
+				URL:          server.URL,
+				Size:         int64(len(content)),
+				SHA256:       hex.EncodeToString(checksum[:]),
+				LastModified: "Fri, 28 Oct 2022 06:01:00 GMT",
+				ETag:         `"v1"`,
+			}
+			if got := stream.Info(); got != want {
+				t.Errorf("Info() = %+v, want %+v", got, want)
+			}
+			if err := stream.Close(); err != nil {
+				t.Errorf("Close() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestDownloadFilingStreamTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name  string
+		pause time.Duration
+		// consumerPause is the time the consumer takes before each read
+		consumerPause time.Duration
+		wantErr       bool
+	}{
+		{name: "Slow transfer longer than the timeout", pause: timeout / 4},
+		{name: "Slow consumer", pause: timeout / 4, consumerPause: 3 * timeout},
+		{name: "Stalled transfer", pause: 3 * timeout, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Send the document in chunks, the whole transfer taking longer than the timeout
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for i := 0; i < 6; i++ {
+					w.Write([]byte("chunk "))
+					w.(http.Flusher).Flush()
+					select {
+					case <-time.After(tt.pause):
+					case <-r.Context().Done():
+						return
+					}
+				}
+			}))
+			defer server.Close()
+
+			client := NewSECClient("TestCompany", "test@example.com", WithTimeout(timeout), WithRetryPolicy(fastRetryPolicy))
+			stream, err := client.DownloadFilingStream(server.URL)
+			if err != nil {
+				t.Fatalf("DownloadFilingStream() error = %v", err)
+			}
+			defer stream.Close()
+
+			data, err := io.ReadAll(readerFunc(func(p []byte) (int, error) {
+				time.Sleep(tt.consumerPause)
+				return stream.Read(p)
+			}))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Read() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !isRetryableError(err) || !strings.Contains(err.Error(), "no data received") {
+					t.Errorf("Read() error = %v, want a retryable timeout", err)
+				}
+				return
+			}
+			if string(data) != strings.Repeat("chunk ", 6) {
+				t.Errorf("Read() = %q, want 6 chunks", data)
+			}
+		})
+	}
+}
+
+// readerFunc adapts a function to io.Reader.
+type readerFunc func(p []byte) (int, error)
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	return f(p)
+}
+
+func TestDownloadFilingTo(t *testing.T) {
+	content := "test filing content"
+	checksum := sha256.Sum256([]byte(content))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(content))
+	}))
+	defer server.Close()
+
+	client := NewSECClient("TestCompany", "test@example.com")
+	var buf bytes.Buffer
+	info, err := client.DownloadFilingTo(&buf, server.URL)
+	if err != nil {
+		t.Fatalf("DownloadFilingTo() error = %v", err)
+	}
+	if buf.String() != content {
+		t.Errorf("DownloadFilingTo() wrote %q, want %q", buf.String(), content)
+	}
+	if info.Size != int64(len(content)) || info.SHA256 != hex.EncodeToString(checksum[:]) {
+		t.Errorf("DownloadFilingTo() info = %+v", info)
+	}
+
+	// A body cut short is reported as an error
+	truncated := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)*2))
+		w.Write([]byte(content))
+	}))
+	defer truncated.Close()
+
+	if _, err := client.DownloadFilingTo(io.Discard, truncated.URL); err == nil {
+		t.Errorf("DownloadFilingTo() of a truncated body succeeded, want error")
+	}
+}
+
+func TestFetchAndSaveFilingsStreaming(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"aapl-20220924.htm"},
+		}},
+	}
+	content := strings.Repeat("large primary document ", 1<<16)
+	checksum := sha256.Sum256([]byte(content))
+
+	var truncate atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/submissions/CIK0000320193.json":
+			json.NewEncoder(w).Encode(submissions)
+		case strings.HasSuffix(r.URL.Path, "/aapl-20220924.htm"):
+			w.Write([]byte(content))
+		case truncate.Load():
+			w.Header().Set("Content-Length", "1000")
+			w.Write([]byte("partial index"))
+		default:
+			w.Write([]byte("index"))
+		}
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+		Force:          true,
+	}
+	client := newTestClient(t, server, WithRetryPolicy(fastRetryPolicy))
+
+	t.Run("Documents are streamed to the storage", func(t *testing.T) {
+		report, err := FetchAndSaveFilings(metadata, client)
+		if err != nil {
+			t.Fatalf("FetchAndSaveFilings() error = %v", err)
+		}
+
+		doc := report.Filings[0].Documents[1]
+		if doc.Kind != DocumentPrimary || doc.Bytes != int64(len(content)) || doc.SHA256 != hex.EncodeToString(checksum[:]) {
+			t.Errorf("primary DocumentResult = %+v", doc)
+		}
+		saved, err := os.ReadFile(doc.Path)
+		if err != nil || string(saved) != content {
+			t.Errorf("saved primary document has %d bytes, %v, want %d", len(saved), err, len(content))
+		}
+		assertNoSpool(t, folder)
+	})
+
+	t.Run("Failed downloads are not stored", func(t *testing.T) {
+		truncate.Store(true)
+		defer truncate.Store(false)
+
+		report, err := FetchAndSaveFilings(metadata, client)
+		if err == nil {
+			t.Fatalf("FetchAndSaveFilings() error = nil, want error")
+		}
+		if len(report.Filings) != 1 || report.Filings[0].Err == nil {
+			t.Errorf("FetchAndSaveFilings() filings = %+v, want a failed filing", report.Filings)
+		}
+
+		// Without a validator, the partial index page cannot be resumed and is not kept
+		assertNoSpool(t, folder)
+	})
+}
+
+func TestFetchAndSaveFilingsSpoolRetention(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+		}},
+	}
+
+	tests := []struct {
+		name      string
+		status    int
+		etag      string
+		truncate  bool
+		wantSpool bool
+	}{
+		{name: "Missing index page", status: http.StatusNotFound, etag: `"v1"`},
+		{name: "Forbidden index page", status: http.StatusForbidden, etag: `"v1"`},
+		{name: "Interrupted without validator", status: http.StatusOK, truncate: true},
+		{name: "Interrupted with validator", status: http.StatusOK, etag: `"v1"`, truncate: true, wantSpool: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path == "/submissions/CIK0000320193.json" {
+					json.NewEncoder(w).Encode(submissions)
+					return
+				}
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.truncate {
+					w.Header().Set("Content-Length", "1000")
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte("partial index"))
+			}))
+			defer server.Close()
+
+			folder := t.TempDir()
+			metadata := &DownloadMetadata{
+				DownloadFolder: folder,
+				Form:           "10-K",
+				CIK:            "0000320193",
+				Ticker:         "AAPL",
+				Limit:          10,
+				After:          DefaultAfterDate,
+				Before:         DefaultBeforeDate,
+			}
+			client := NewSECClient("TestCompany", "test@example.com",
+				WithEndpoints(Endpoints{WWWBaseURL: server.URL, DataBaseURL: server.URL, SubmissionsPath: DefaultSubmissionsPath}),
+				WithRetryPolicy(fastRetryPolicy),
+			)
+			if _, err := FetchAndSaveFilings(metadata, client); err == nil {
+				t.Fatalf("FetchAndSaveFilings() error = nil, want error")
+			}
+
+			if !tt.wantSpool {
+				assertNoSpool(t, folder)
+				return
+			}
+			entries, _ := os.ReadDir(filepath.Join(folder, spoolDirName, "0000320193-22-000001.partial"))
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			if len(names) != 2 || slices.Contains(names, spoolLockFilename) {
+				t.Errorf("spool holds %v, want the partial index page and its record, unlocked", names)
+			}
+		})
+	}
+}
+
+func TestFilingSpoolLock(t *testing.T) {
+	root := t.TempDir()
+	const accessionNumber = "0000320193-22-000001"
+
+	// A second download of the filing does not use the locked spool
+	first, err := newFilingSpool(root, accessionNumber)
+	if err != nil {
+		t.Fatalf("newFilingSpool() error = %v", err)
+	}
+	second, err := newFilingSpool(root, accessionNumber)
+	if err != nil {
+		t.Fatalf("newFilingSpool() error = %v", err)
+	}
+	if !first.resumable || second.resumable || second.dir == first.dir {
+		t.Errorf("newFilingSpool() = %+v and %+v, want a resumable and a temporary spool", first, second)
+	}
+
+	// Neither spool is swept while in use
+	if removed, err := removeStaleSpools(root, 0); err != nil || removed != 0 {
+		t.Errorf("removeStaleSpools() = %d, %v, want 0", removed, err)
+	}
+	second.release(context.Canceled)
+	if _, err := os.Stat(second.dir); !os.IsNotExist(err) {
+		t.Errorf("temporary spool %s was kept (stat error = %v)", second.dir, err)
+	}
+
+	// A lock that was not refreshed for long was left by a crashed process and is taken over
+	first.lock.unlock()
+	old := time.Now().Add(-2 * spoolLockStaleAge)
+	if err := os.WriteFile(filepath.Join(first.dir, spoolLockFilename), nil, 0644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(first.dir, spoolLockFilename), old, old); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+	third, err := newFilingSpool(root, accessionNumber)
+	if err != nil {
+		t.Fatalf("newFilingSpool() error = %v", err)
+	}
+	if !third.resumable || third.dir != first.dir {
+		t.Errorf("newFilingSpool() = %+v, want the stale spool %s", third, first.dir)
+	}
+
+	// Releasing an empty spool removes it
+	third.release(context.Canceled)
+	if _, err := os.Stat(third.dir); !os.IsNotExist(err) {
+		t.Errorf("empty spool %s was kept (stat error = %v)", third.dir, err)
+	}
+}
+
+func TestFileLock(t *testing.T) {
+	const staleAge = 40 * time.Millisecond
+
+	t.Run("Refreshed while held", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lock")
+		lock, err := tryLockFile(path, staleAge)
+		if err != nil || lock == nil {
+			t.Fatalf("tryLockFile() = %v, %v, want lock", lock, err)
+		}
+		defer lock.unlock()
+
+		time.Sleep(3 * staleAge)
+		if other, err := tryLockFile(path, staleAge); err != nil || other != nil {
+			t.Errorf("tryLockFile() of a held lock = %v, %v, want nil", other, err)
+		}
+	})
+
+	t.Run("Stale lock taken over once", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "lock")
+		old := time.Now().Add(-time.Hour)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatalf("os.WriteFile() error = %v", err)
+		}
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatalf("os.Chtimes() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		locks := make(chan *fileLock, 8)
+		for range cap(locks) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lock, err := tryLockFile(path, time.Minute)
+				if err != nil {
+					t.Errorf("tryLockFile() error = %v", err)
+				}
+				if lock != nil {
+					locks <- lock
+				}
+			}()
+		}
+		wg.Wait()
+		close(locks)
+
+		holders := 0
+		for lock := range locks {
+			holders++
+			lock.unlock()
+		}
+		if holders != 1 {
+			t.Errorf("%d processes took the stale lock over, want 1", holders)
+		}
+	})
+}
+
+func TestDownloaderRemoveStaleSpools(t *testing.T) {
+	folder := t.TempDir()
+	downloader, err := NewDownloader("TestCompany", "test@example.com", folder,
+		WithTickerToCIKMap(map[string]string{"AAPL": "0000320193"}),
+	)
+	if err != nil {
+		t.Fatalf("NewDownloader() error = %v", err)
+	}
+
+	// An abandoned spool, a recent one, an old one still in use, and an old directory of
+	// the download folder that is not a spool
+	old := time.Now().Add(-2 * DefaultSpoolMaxAge)
+	spools := map[string]bool{
+		filepath.Join(spoolDirName, "0000320193-22-000001.partial"): true,
+		filepath.Join(spoolDirName, "0000320193-22-000002.partial"): false,
+		filepath.Join(spoolDirName, "0000320193-22-000003.partial"): false,
+		".0000320193-22-000004.partial":                             false,
+	}
+	for name := range spools {
+		if err := os.MkdirAll(filepath.Join(folder, name), 0755); err != nil {
+			t.Fatalf("os.MkdirAll() error = %v", err)
+		}
+	}
+	lock, err := lockSpool(filepath.Join(folder, spoolDirName, "0000320193-22-000003.partial"))
+	if err != nil || lock == nil {
+		t.Fatalf("lockSpool() = %v, %v, want lock", lock, err)
+	}
+	defer lock.unlock()
+	for name := range spools {
+		if err := os.Chtimes(filepath.Join(folder, name), old, old); err != nil {
+			t.Fatalf("os.Chtimes() error = %v", err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(folder, spoolDirName, "0000320193-22-000002.partial"), time.Now(), time.Now()); err != nil {
+		t.Fatalf("os.Chtimes() error = %v", err)
+	}
+
+	removed, err := downloader.RemoveStaleSpools(DefaultSpoolMaxAge)
+	if err != nil || removed != 1 {
+		t.Errorf("RemoveStaleSpools() = %d, %v, want 1", removed, err)
+	}
+	for name, wantRemoved := range spools {
+		if _, err := os.Stat(filepath.Join(folder, name)); os.IsNotExist(err) != wantRemoved {
+			t.Errorf("spool %s removed = %v, want %v", name, os.IsNotExist(err), wantRemoved)
+		}
+	}
+}
+
+// assertNoSpool checks that no spool or staging directory is left in a download folder.
+func assertNoSpool(t *testing.T, folder string) {
+	t.Helper()
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Name() != spoolDirName && isTemporaryName(entry.Name()) {
+			t.Errorf("download folder contains %s after the download", entry.Name())
+		}
+	}
+	spools, _ := os.ReadDir(filepath.Join(folder, spoolDirName))
+	for _, entry := range spools {
+		t.Errorf("spool directory contains %s after the download", entry.Name())
+	}
+}
+
+func TestResumeFilingTo(t *testing.T) {
+	content := strings.Repeat("resumable document ", 1000)
+	changed := strings.Repeat("changed document ", 1000)
+	modTime := time.Date(2022, 10, 28, 6, 1, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		// serverContent and etag are served with Range support unless ignoreRange is set
+		serverContent string
+		etag          string
+		ignoreRange   bool
+		// prefix is the content of the file before the call
+		prefix    string
+		partial   DocumentInfo
+		wantRange string
+	}{
+		{
+			name:          "Unchanged document is resumed",
+			serverContent: content,
+			etag:          `"v1"`,
+			prefix:        content[:5000],
+			partial:       DocumentInfo{ETag: `"v1"`},
+			wantRange:     "bytes=5000-",
+		},
+		{
+			name:          "Unchanged document is resumed with Last-Modified",
+			serverContent: content,
+			prefix:        content[:5000],
+			partial:       DocumentInfo{LastModified: modTime.Format(http.TimeFormat)},
+			wantRange:     "bytes=5000-",
+		},
+		{
+			name:          "Changed document is downloaded again",
+			serverContent: changed,
+			etag:          `"v2"`,
+			prefix:        content[:5000],
+			partial:       DocumentInfo{ETag: `"v1"`},
+			wantRange:     "bytes=5000-",
+		},
+		{
+			name:          "Complete document is not downloaded again",
+			serverContent: content,
+			etag:          `"v1"`,
+			prefix:        content,
+			partial:       DocumentInfo{ETag: `"v1"`},
+			wantRange:     fmt.Sprintf("bytes=%d-", len(content)),
+		},
+		{
+			name:          "Ignored range restarts the download",
+			serverContent: content,
+			etag:          `"v1"`,
+			ignoreRange:   true,
+			prefix:        content[:5000],
+			partial:       DocumentInfo{ETag: `"v1"`},
+			wantRange:     "bytes=5000-",
+		},
+		{
+			name:          "Download without validators starts from scratch",
+			serverContent: content,
+			etag:          `"v1"`,
+			prefix:        "stale bytes",
+		},
+		{
+			name:          "Weak ETag is not used",
+			serverContent: content,
+			etag:          `W/"v1"`,
+			prefix:        content[:5000],
+			partial:       DocumentInfo{ETag: `W/"v1"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange atomic.Value
+			gotRange.Store("")
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange.Store(r.Header.Get("Range"))
+				if tt.etag != "" {
+					w.Header().Set("ETag", tt.etag)
+				}
+				if tt.ignoreRange {
+					w.Write([]byte(tt.serverContent))
+					return
+				}
+				http.ServeContent(w, r, "", modTime, strings.NewReader(tt.serverContent))
+			}))
+			defer server.Close()
+
+			file, err := os.Create(filepath.Join(t.TempDir(), "document.part"))
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			defer file.Close()
+			file.WriteString(tt.prefix)
+
+			client := NewSECClient("TestCompany", "test@example.com")
+			info, err := client.ResumeFilingTo(file, server.URL, tt.partial)
+			if err != nil {
+				t.Fatalf("ResumeFilingTo() error = %v", err)
+			}
+
+			if got := gotRange.Load().(string); got != tt.wantRange {
+				t.Errorf("ResumeFilingTo() requested Range %q, want %q", got, tt.wantRange)
+			}
+			saved, _ := os.ReadFile(file.Name())
+			if string(saved) != tt.serverContent {
+				t.Errorf("ResumeFilingTo() left %d bytes in the file, want %d", len(saved), len(tt.serverContent))
+			}
+			checksum := sha256.Sum256([]byte(tt.serverContent))
+			if info.Size != int64(len(tt.serverContent)) || info.SHA256 != hex.EncodeToString(checksum[:]) {
+				t.Errorf("ResumeFilingTo() info = %+v, want the size and checksum of the whole document", info)
+			}
+			if tt.etag != "" && info.ETag != tt.etag {
+				t.Errorf("ResumeFilingTo() ETag = %q, want %q", info.ETag, tt.etag)
+			}
+		})
+	}
+}
+
+func TestParseContentRange(t *testing.T) {
+	tests := []struct {
+		value        string
+		wantFirst    int64
+		wantComplete int64
+		wantOK       bool
+	}{
+		{value: "bytes 100-199/200", wantFirst: 100, wantComplete: 200, wantOK: true},
+		{value: "bytes 100-199/*", wantFirst: 100, wantComplete: -1, wantOK: true},
+		{value: "bytes */200", wantFirst: -1, wantComplete: 200, wantOK: true},
+		{value: "bytes */*"},
+		{value: ""},
+		{value: "items 0-1/2"},
+		{value: "bytes 100/200"},
+		{value: "bytes x-199/200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			first, complete, ok := parseContentRange(tt.value)
+			if ok != tt.wantOK || (ok && (first != tt.wantFirst || complete != tt.wantComplete)) {
+				t.Errorf("parseContentRange(%q) = %d, %d, %v, want %d, %d, %v",
+					tt.value, first, complete, ok, tt.wantFirst, tt.wantComplete, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestFetchAndSaveFilingsResume(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{Recent: FilingColumns{
+			AccessionNumber: []string{"0000320193-22-000001"},
+			FilingDate:      []string{"2022-10-28"},
+			Form:            []string{"10-K"},
+		}},
+	}
+
+	// The index page is served with Range support, and cut short while interrupt is set
+	var interrupt atomic.Bool
+	var index atomic.Value
+	var ranges atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/submissions/CIK0000320193.json" {
+			json.NewEncoder(w).Encode(submissions)
+			return
+		}
+
+		document := index.Load().(string)
+		sum := sha256.Sum256([]byte(document))
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:8])+`"`)
+		if r.Header.Get("Range") != "" {
+			ranges.Add(1)
+		}
+		if interrupt.Load() {
+			w.Header().Set("Content-Length", strconv.Itoa(len(document)))
+			w.Write([]byte(document[:len(document)/2]))
+			return
+		}
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(document))
+	}))
+	defer server.Close()
+
+	folder := t.TempDir()
+	metadata := &DownloadMetadata{
+		DownloadFolder: folder,
+		Form:           "10-K",
+		CIK:            "0000320193",
+		Ticker:         "AAPL",
+		Limit:          10,
+		After:          DefaultAfterDate,
+		Before:         DefaultBeforeDate,
+		Force:          true,
+	}
+	// Interruptions are not retried, so that the next download has to resume them
+	client := newTestClient(t, server, WithRetryPolicy(RetryPolicy{MaxAttempts: 1}))
+
+	tests := []struct {
+		name string
+		// before and after are the index page served by the interrupted and the next download
+		before     string
+		after      string
+		wantRanges int32
+	}{
+		{
+			name:       "Unchanged document is resumed",
+			before:     strings.Repeat("index page ", 10000),
+			after:      strings.Repeat("index page ", 10000),
+			wantRanges: 1,
+		},
+		{
+			name:       "Changed document is downloaded again",
+			before:     strings.Repeat("index page ", 10000),
+			after:      strings.Repeat("amended index page ", 10000),
+			wantRanges: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ranges.Store(0)
+
+			// The interrupted download keeps the part it received
+			index.Store(tt.before)
+			interrupt.Store(true)
+			report, err := FetchAndSaveFilings(metadata, client)
+			interrupt.Store(false)
+			if err == nil || report.Filings[0].Err == nil {
+				t.Fatalf("FetchAndSaveFilings() of an interrupted download succeeded, want error")
+			}
+			spooled, _ := filepath.Glob(filepath.Join(folder, spoolDirName, "0000320193-22-000001.partial", "*-*"))
+			if len(spooled) != 1 {
+				t.Fatalf("spool holds %v, want the partial index page", spooled)
+			}
+			if info, err := os.Stat(spooled[0]); err != nil || info.Size() != int64(len(tt.before)/2) {
+				t.Fatalf("partial index page = %v, %v, want %d bytes", info, err, len(tt.before)/2)
+			}
+
+			// The next download resumes it
+			index.Store(tt.after)
+			report, err = FetchAndSaveFilings(metadata, client)
+			if err != nil {
+				t.Fatalf("FetchAndSaveFilings() error = %v", err)
+			}
+			if got := ranges.Load(); got != tt.wantRanges {
+				t.Errorf("FetchAndSaveFilings() made %d range requests, want %d", got, tt.wantRanges)
+			}
+
+			doc := report.Filings[0].Documents[0]
+			checksum := sha256.Sum256([]byte(tt.after))
+			if doc.Bytes != int64(len(tt.after)) || doc.SHA256 != hex.EncodeToString(checksum[:]) {
+				t.Errorf("index DocumentResult = %+v, want the size and checksum of the whole page", doc)
+			}
+			saved, err := os.ReadFile(doc.Path)
+			if err != nil || string(saved) != tt.after {
+				t.Errorf("saved index page has %d bytes, %v, want %d", len(saved), err, len(tt.after))
+			}
+			assertNoSpool(t, folder)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package sec
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"sort"
+	"sync"
+)
+
+// submissionSource loads a company's submission history on demand and caches it,
+// so that several downloads for the same company fetch the submissions file and
+// each page of older filings at most once. It is safe for concurrent use.
+type submissionSource struct {
+	client *SECClient
+	cik    string
+
+	mu    sync.Mutex
+	data  *SubmissionData
+	pages map[string]*FilingColumns
+}
+
+// newSubmissionSource creates a submission source for the given CIK.
+func newSubmissionSource(client *SECClient, cik string) *submissionSource {
+	return &submissionSource{
+		client: client,
+		cik:    cik,
+		pages:  make(map[string]*FilingColumns),
+	}
+}
+
+// submissions returns the company's submissions file, fetching it on first use.
+func (s *submissionSource) submissions(ctx context.Context) (*SubmissionData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.data != nil {
+		return s.data, nil
+	}
+
+	// Format the submission URL
+	submissionFile := fmt.Sprintf(SubmissionFileFormat, s.cik)
+	submissionURL := s.client.endpoints.SubmissionsURL(submissionFile)
+
+	// Get the list of available filings
+	data, err := s.client.GetListOfAvailableFilingsWithContext(ctx, submissionURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get list of available filings: %w", err)
+	}
+
+	s.data = data
+	return data, nil
+}
+
+// page returns a page of older filings, fetching it on first use.
+func (s *submissionSource) page(ctx context.Context, name string) (*FilingColumns, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if page, ok := s.pages[name]; ok {
+		return page, nil
+	}
+
+	page, err := s.client.GetSubmissionsPageWithContext(ctx, s.client.endpoints.SubmissionsURL(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submissions page %s: %w", name, err)
+	}
+
+	s.pages[name] = page
+	return page, nil
+}
+
+// filings returns an iterator over the company's filings, newest first. The submissions
+// file is fetched when iteration starts and each page of older filings only when the
+// iteration reaches it. After an error is yielded, iteration stops.
+func (s *submissionSource) filings(ctx context.Context) iter.Seq2[FilingInfo, error] {
+	return func(yield func(FilingInfo, error) bool) {
+		// Get the list of available filings
+		data, err := s.submissions(ctx)
+		if err != nil {
+			yield(FilingInfo{}, err)
+			return
+		}
+
+		// Yield the most recent filings first
+		for i := 0; i < data.Filings.Recent.Len(); i++ {
+			if !yield(data.Filings.Recent.Filing(i), nil) {
+				return
+			}
+		}
+
+		// Then walk the older pages, newest first
+		for _, file := range newestSubmissionFilesFirst(data.Filings.Files) {
+			page, err := s.page(ctx, file.Name)
+			if err != nil {
+				yield(FilingInfo{}, err)
+				return
+			}
+
+			for i := 0; i < page.Len(); i++ {
+				if !yield(page.Filing(i), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// newestSubmissionFilesFirst returns a copy of the submission files sorted by the
+// date of their most recent filing, newest first.
+func newestSubmissionFilesFirst(files []SubmissionFile) []SubmissionFile {
+	sorted := make([]SubmissionFile, len(files))
+	copy(sorted, files)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].FilingTo > sorted[j].FilingTo
+	})
+	return sorted
+}
+
+// Len returns the number of filings in the columns.
+func (c *FilingColumns) Len() int {
+	return len(c.AccessionNumber)
+}
+
+// Filing returns the metadata of the i-th filing of the columns, with the items parsed
+// and the XBRL flags converted to booleans. Missing columns leave the corresponding
+// fields empty. It panics if i is out of range [0, Len()).
+func (c *FilingColumns) Filing(i int) FilingInfo {
+	info := FilingInfo{AccessionNumber: c.AccessionNumber[i]}
+	if i < len(c.FilingDate) {
+		info.FilingDate = c.FilingDate[i]
+	}
+	if i < len(c.Form) {
+		info.Form = c.Form[i]
+	}
+	if i < len(c.PrimaryDocument) {
+		info.PrimaryDocument = c.PrimaryDocument[i]
+	}
+	if i < len(c.Items) {
+		info.Items = ParseItems(c.Items[i])
+	}
+	if i < len(c.ReportDate) {
+		info.ReportDate = c.ReportDate[i]
+	}
+	if i < len(c.AcceptanceDateTime) {
+		info.AcceptanceDateTime = c.AcceptanceDateTime[i]
+	}
+	if i < len(c.Act) {
+		info.Act = c.Act[i]
+	}
+	if i < len(c.FileNumber) {
+		info.FileNumber = c.FileNumber[i]
+	}
+	if i < len(c.FilmNumber) {
+		info.FilmNumber = c.FilmNumber[i]
+	}
+	if i < len(c.Size) {
+		info.Size = c.Size[i]
+	}
+	if i < len(c.IsXBRL) {
+		info.IsXBRL = c.IsXBRL[i] != 0
+	}
+	if i < len(c.IsInlineXBRL) {
+		info.IsInlineXBRL = c.IsInlineXBRL[i] != 0
+	}
+	if i < len(c.CoreType) {
+		info.CoreType = c.CoreType[i]
+	}
+	if i < len(c.PrimaryDocDescription) {
+		info.PrimaryDocDescription = c.PrimaryDocDescription[i]
+	}
+	return info
+}
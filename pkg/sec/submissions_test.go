@@ -0,0 +1,152 @@
+package sec
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestDownloaderFilings(t *testing.T) {
+	submissions := SubmissionData{
+		CIK: "320193",
+		Filings: SubmissionFilings{
+			Recent: FilingColumns{
+				AccessionNumber: []string{"0000320193-22-000002", "0000320193-22-000001"},
+				FilingDate:      []string{"2022-10-28", "2022-07-29"},
+				Form:            []string{"10-K", "10-Q"},
+				PrimaryDocument: []string{"a.htm", "b.htm"},
+			},
+			Files: []SubmissionFile{
+				{Name: "CIK0000320193-submissions-002.json", FilingCount: 1, FilingFrom: "1994-01-01", FilingTo: "1999-12-31"},
+				{Name: "CIK0000320193-submissions-001.json", FilingCount: 1, FilingFrom: "2000-01-01", FilingTo: "2010-12-31"},
+			},
+		},
+	}
+	pages := map[string]FilingColumns{
+		"/submissions/CIK0000320193-submissions-001.json": {
+			AccessionNumber: []string{"0000320193-08-000001"},
+			FilingDate:      []string{"2008-11-05"},
+			Form:            []string{"10-K"},
+			PrimaryDocument: []string{"d10k.htm"},
+		},
+		"/submissions/CIK0000320193-submissions-002.json": {
+			AccessionNumber: []string{"0000320193-98-000001"},
+			FilingDate:      []string{"1998-12-01"},
+			Form:            []string{"10-K405"},
+		},
+	}
+
+	newServer := func(t *testing.T, failPages bool) (*Downloader, func() []string) {
+		var mu sync.Mutex
+		var requested []string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requested = append(requested, r.URL.Path)
+			mu.Unlock()
+
+			if r.URL.Path == "/submissions/CIK0000320193.json" {
+				json.NewEncoder(w).Encode(submissions)
+				return
+			}
+			page, ok := pages[r.URL.Path]
+			if !ok || failPages {
+				http.NotFound(w, r)
+				return
+			}
+			json.NewEncoder(w).Encode(page)
+		}))
+		t.Cleanup(server.Close)
+
+		downloader := &Downloader{
+			client:         newTestClient(t, server),
+			tickerToCIKMap: map[string]string{"AAPL": "0000320193"},
+		}
+		return downloader, func() []string {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(requested)
+		}
+	}
+
+	t.Run("Walks every page newest first", func(t *testing.T) {
+		downloader, requested := newServer(t, false)
+
+		var got []string
+		for filing, err := range downloader.Filings("AAPL") {
+			if err != nil {
+				t.Fatalf("Filings() error = %v", err)
+			}
+			got = append(got, filing.AccessionNumber)
+		}
+
+		want := []string{"0000320193-22-000002", "0000320193-22-000001", "0000320193-08-000001", "0000320193-98-000001"}
+		if !slices.Equal(got, want) {
+			t.Errorf("Filings() = %v, want %v", got, want)
+		}
+		if n := len(requested()); n != 3 {
+			t.Errorf("Filings() made %d requests, want 3", n)
+		}
+	})
+
+	t.Run("Breaking stops fetching pages", func(t *testing.T) {
+		downloader, requested := newServer(t, false)
+
+		for filing, err := range downloader.Filings("AAPL") {
+			if err != nil {
+				t.Fatalf("Filings() error = %v", err)
+			}
+			if filing.AccessionNumber == "0000320193-08-000001" {
+				break
+			}
+		}
+
+		want := []string{"/submissions/CIK0000320193.json", "/submissions/CIK0000320193-submissions-001.json"}
+		if got := requested(); !slices.Equal(got, want) {
+			t.Errorf("Filings() requested %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Nothing is fetched before iteration", func(t *testing.T) {
+		downloader, requested := newServer(t, false)
+
+		_ = downloader.Filings("AAPL")
+		if got := requested(); len(got) != 0 {
+			t.Errorf("Filings() requested %v before iteration, want nothing", got)
+		}
+	})
+
+	t.Run("Page errors end the iteration", func(t *testing.T) {
+		downloader, _ := newServer(t, true)
+
+		var got []string
+		var gotErr error
+		for filing, err := range downloader.Filings("AAPL") {
+			if err != nil {
+				gotErr = err
+				continue
+			}
+			got = append(got, filing.AccessionNumber)
+		}
+
+		if !errors.Is(gotErr, ErrNotFound) {
+			t.Errorf("Filings() error = %v, want ErrNotFound", gotErr)
+		}
+		if want := []string{"0000320193-22-000002", "0000320193-22-000001"}; !slices.Equal(got, want) {
+			t.Errorf("Filings() = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("Invalid ticker", func(t *testing.T) {
+		downloader, _ := newServer(t, false)
+
+		for _, err := range downloader.Filings("INVALID") {
+			if err == nil {
+				t.Errorf("Filings() yielded a filing for an invalid ticker")
+			}
+		}
+	})
+}
@@ -12,6 +12,8 @@ type DownloadMetadata struct {
 	DownloadFolder string
 	// Form is the SEC form type to download (e.g., "10-K", "8-K")
 	Form string
+	// Forms lists additional form types to download along with Form
+	Forms []string
 	// CIK is the Central Index Key that identifies the company
 	CIK string
 	// Limit is the maximum number of filings to download (0 for all available)
@@ -28,8 +30,31 @@ type DownloadMetadata struct {
 	Ticker string
 	// AccessionNumbersToSkip is a map of accession numbers to skip during download
 	AccessionNumbersToSkip map[string]bool
+	// Concurrency is the maximum number of filings downloaded in parallel (values below 1 mean 1)
+	Concurrency int
+	// Items lists the 8-K item codes to download (e.g., "2.02"); empty means all filings
+	Items []string
+	// Filters lists custom predicates that every downloaded filing must satisfy
+	Filters []FilingFilter
+	// SkipExisting determines whether to skip filings whose documents all exist in the storage
+	SkipExisting bool
+	// RepairMissing determines whether to download only the missing documents of filings
+	// that exist in the storage; it implies SkipExisting
+	RepairMissing bool
+	// Force determines whether to download every filing even if it exists in the storage
+	Force bool
+	// Manifest records the provenance of every saved document (optional)
+	Manifest *Manifest
+	// Storage is the backend saved documents are written to (defaults to files below DownloadFolder)
+	Storage Storage
+	// Layout determines the storage key of every saved document (defaults to DefaultLayout)
+	Layout *Layout
 }
 
+// FilingFilter is a predicate over the metadata of a filing.
+// It returns true if the filing should be downloaded.
+type FilingFilter func(FilingInfo) bool
+
 // ToDownload represents a single filing document to be downloaded.
 // It contains the necessary URIs and identifiers to locate and save the filing.
 type ToDownload struct {
@@ -41,6 +66,19 @@ type ToDownload struct {
 	AccessionNumber string
 	// DetailsDocSuffix is the suffix for detail documents
 	DetailsDocSuffix string
+	// Form is the SEC form type of the filing
+	Form string
+	// RequestedForm is the requested form type the filing matched, without the amendment suffix
+	RequestedForm string
+	// FilingDate is the date when the filing was submitted in "YYYY-MM-DD" format
+	FilingDate string
+	// Items lists the item codes reported by the filing (e.g., for 8-K filings)
+	Items []string
+	// Info contains the metadata of the filing from the submissions API
+	Info FilingInfo
+	// Err is set if the filing cannot be downloaded, e.g. because the submissions API
+	// returned an unsafe document name; such filings are reported as failed
+	Err error
 }
 
 // TickerCIKEntry represents a single entry in the ticker to CIK mapping.
@@ -66,7 +104,8 @@ type TickerMetadata struct {
 }
 
 // FilingInfo represents information about a specific SEC filing.
-// It contains metadata such as accession number, filing date, and form type.
+// It contains metadata such as accession number, filing date, and form type,
+// and is passed to the filters set with WithFilter.
 type FilingInfo struct {
 	// AccessionNumber is the unique identifier for the filing
 	AccessionNumber string `json:"accessionNumber"`
@@ -78,6 +117,85 @@ type FilingInfo struct {
 	PrimaryDocument string `json:"primaryDocument"`
 	// Items contains the items covered in the filing (e.g., for 8-K filings)
 	Items []string `json:"items"`
+	// ReportDate is the end of the period covered by the filing in "YYYY-MM-DD" format (may be empty)
+	ReportDate string `json:"reportDate"`
+	// AcceptanceDateTime is the time when EDGAR accepted the filing (e.g., "2023-11-03T18:04:43.000Z")
+	AcceptanceDateTime string `json:"acceptanceDateTime"`
+	// Act is the securities act under which the filing was made (e.g., "34")
+	Act string `json:"act"`
+	// FileNumber is the SEC file number of the filer (e.g., "001-36743")
+	FileNumber string `json:"fileNumber"`
+	// FilmNumber is the film number assigned to the filing
+	FilmNumber string `json:"filmNumber"`
+	// Size is the size of the complete filing in bytes
+	Size int64 `json:"size"`
+	// IsXBRL determines whether the filing includes XBRL financial data
+	IsXBRL bool `json:"isXBRL"`
+	// IsInlineXBRL determines whether the filing includes inline XBRL
+	IsInlineXBRL bool `json:"isInlineXBRL"`
+	// CoreType is the core form type of the filing (e.g., "10-K")
+	CoreType string `json:"core_type"`
+	// PrimaryDocDescription is the description of the primary document
+	PrimaryDocDescription string `json:"primaryDocDescription"`
+}
+
+// FilingColumns holds filing metadata in the columnar layout used by the SEC
+// submissions API. Each slice is indexed by filing, so the i-th element of every
+// column describes the same filing. The same layout is used both for the
+// "filings.recent" object and for the paginated CIK##########-submissions-NNN.json files.
+type FilingColumns struct {
+	// AccessionNumber is an array of accession numbers
+	AccessionNumber []string `json:"accessionNumber"`
+	// FilingDate is an array of filing dates
+	FilingDate []string `json:"filingDate"`
+	// Form is an array of form types
+	Form []string `json:"form"`
+	// PrimaryDocument is an array of primary document filenames
+	PrimaryDocument []string `json:"primaryDocument"`
+	// Items is an array of arrays containing items covered in each filing
+	Items []string `json:"items"`
+	// ReportDate is an array of period of report dates
+	ReportDate []string `json:"reportDate"`
+	// AcceptanceDateTime is an array of EDGAR acceptance times
+	AcceptanceDateTime []string `json:"acceptanceDateTime"`
+	// Act is an array of securities acts
+	Act []string `json:"act"`
+	// FileNumber is an array of SEC file numbers
+	FileNumber []string `json:"fileNumber"`
+	// FilmNumber is an array of film numbers
+	FilmNumber []string `json:"filmNumber"`
+	// Size is an array of filing sizes in bytes
+	Size []int64 `json:"size"`
+	// IsXBRL is an array of XBRL flags (1 if the filing includes XBRL, 0 otherwise)
+	IsXBRL []int `json:"isXBRL"`
+	// IsInlineXBRL is an array of inline XBRL flags (1 if the filing includes inline XBRL, 0 otherwise)
+	IsInlineXBRL []int `json:"isInlineXBRL"`
+	// CoreType is an array of core form types
+	CoreType []string `json:"core_type"`
+	// PrimaryDocDescription is an array of primary document descriptions
+	PrimaryDocDescription []string `json:"primaryDocDescription"`
+}
+
+// SubmissionFile describes an additional page of a company's submission history.
+// The SEC only inlines the most recent filings in the submissions file; older
+// filings are split across the files listed in "filings.files".
+type SubmissionFile struct {
+	// Name is the file name of the page (e.g., "CIK0000320193-submissions-001.json")
+	Name string `json:"name"`
+	// FilingCount is the number of filings contained in the page
+	FilingCount int `json:"filingCount"`
+	// FilingFrom is the earliest filing date in the page in "YYYY-MM-DD" format
+	FilingFrom string `json:"filingFrom"`
+	// FilingTo is the latest filing date in the page in "YYYY-MM-DD" format
+	FilingTo string `json:"filingTo"`
+}
+
+// SubmissionFilings contains the filing data of a submissions file.
+type SubmissionFilings struct {
+	// Recent contains the most recent filings
+	Recent FilingColumns `json:"recent"`
+	// Files lists the additional pages holding older filings
+	Files []SubmissionFile `json:"files"`
 }
 
 // SubmissionData represents the complete submission data for a CIK.
@@ -85,20 +203,9 @@ type FilingInfo struct {
 type SubmissionData struct {
 	// CIK is the Central Index Key that identifies the company
 	CIK string `json:"cik"`
+	// CompanyProfile contains the company information; its CIK is left empty
+	// in favor of the CIK above, use Profile to get a complete profile
+	CompanyProfile
 	// Filings contains the filing data
-	Filings struct {
-		// Recent contains the most recent filings
-		Recent struct {
-			// AccessionNumber is an array of accession numbers
-			AccessionNumber []string `json:"accessionNumber"`
-			// FilingDate is an array of filing dates
-			FilingDate []string `json:"filingDate"`
-			// Form is an array of form types
-			Form []string `json:"form"`
-			// PrimaryDocument is an array of primary document filenames
-			PrimaryDocument []string `json:"primaryDocument"`
-			// Items is an array of arrays containing items covered in each filing
-			Items []string `json:"items"`
-		} `json:"recent"`
-	} `json:"filings"`
+	Filings SubmissionFilings `json:"filings"`
 }